@@ -0,0 +1,68 @@
+package cds
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+)
+
+var cohortIDPattern = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+// Cohort is a group of participants, e.g. of a campus, a study or a region, whose symptoms
+// are reported together
+type Cohort struct {
+	ID string `mapstructure:"id"`
+	// Name is the name of the study shown to participants
+	Name string `mapstructure:"name"`
+}
+
+// SetCohorts sets the cohorts whose symptom reports are served. Requests without a cohort
+// are served with the default cohort.
+func (cds *CDS) SetCohorts(cohorts []Cohort, defaultCohort string) error {
+	byID := make(map[string]Cohort, len(cohorts))
+	for _, cohort := range cohorts {
+		if !cohortIDPattern.MatchString(cohort.ID) {
+			return fmt.Errorf("invalid cohort id %q", cohort.ID)
+		}
+		if _, ok := byID[cohort.ID]; ok {
+			return fmt.Errorf("cohort %s is duplicated", cohort.ID)
+		}
+		if cohort.Name == "" {
+			return fmt.Errorf("cohort %s has no name", cohort.ID)
+		}
+		byID[cohort.ID] = cohort
+	}
+	if _, ok := byID[defaultCohort]; !ok {
+		return fmt.Errorf("default cohort %s is not configured", defaultCohort)
+	}
+
+	cds.cohorts = byID
+	cds.defaultCohort = defaultCohort
+	return nil
+}
+
+// lookupCohort returns the cohort of an id, or the default cohort if it is empty
+func (cds *CDS) lookupCohort(id string) (Cohort, error) {
+	if id == "" {
+		id = cds.defaultCohort
+	}
+
+	cohort, ok := cds.cohorts[id]
+	if !ok {
+		return Cohort{}, fmt.Errorf("unknown cohort %s", id)
+	}
+	return cohort, nil
+}
+
+// cohortParam returns the cohort given by the cohort query parameter. The error response is
+// written if it returns false.
+func (cds *CDS) cohortParam(c *gin.Context) (Cohort, bool) {
+	cohort, err := cds.lookupCohort(c.Query("cohort"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return Cohort{}, false
+	}
+	return cohort, true
+}
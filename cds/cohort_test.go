@@ -0,0 +1,37 @@
+package cds
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testCohorts = []Cohort{
+	{
+		ID:   "berkeley",
+		Name: "UC Berkeley Safe Campus Study",
+	},
+	{
+		ID:   "davis",
+		Name: "UC Davis Campus Study",
+	},
+}
+
+func TestSetCohorts(t *testing.T) {
+	cds := New(nil, nil)
+	assert.NoError(t, cds.SetCohorts(testCohorts, "berkeley"))
+
+	cohort, err := cds.lookupCohort("")
+	assert.NoError(t, err)
+	assert.Equal(t, "berkeley", cohort.ID)
+	cohort, err = cds.lookupCohort("davis")
+	assert.NoError(t, err)
+	assert.Equal(t, "UC Davis Campus Study", cohort.Name)
+	_, err = cds.lookupCohort("unknown")
+	assert.Error(t, err)
+
+	assert.Error(t, cds.SetCohorts(testCohorts, "unknown"))
+	assert.Error(t, cds.SetCohorts(append(testCohorts, testCohorts[0]), "berkeley"))
+	assert.Error(t, cds.SetCohorts([]Cohort{{ID: "Berkeley Campus", Name: "Berkeley"}}, "Berkeley Campus"))
+	assert.Error(t, cds.SetCohorts([]Cohort{{ID: "berkeley"}}, "berkeley"))
+}
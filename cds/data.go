@@ -17,3 +17,15 @@ func (p *CDS) ExportData(c *gin.Context) {
 
 	c.Data(http.StatusOK, "application/octet-stream", data)
 }
+
+func (p *CDS) DeleteData(c *gin.Context) {
+	accountNumber := c.GetString("account_number")
+
+	err := p.dataStorePool.Community().DeleteData(c, accountNumber)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"result": "ok"})
+}
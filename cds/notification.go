@@ -0,0 +1,264 @@
+package cds
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/bitmark-inc/data-store/notification"
+	"github.com/bitmark-inc/data-store/outbox"
+	"github.com/bitmark-inc/data-store/store"
+)
+
+// OutboxKindNotification is the kind of outbox messages which notify users of symptom reports
+const OutboxKindNotification = "notification"
+
+// kinds of notifications sent by the community data store
+const (
+	notificationNewReport      = "new_report"
+	notificationSymptomAlert   = "symptom_alert"
+	notificationPOIScoreChange = "poi_score_change"
+)
+
+const (
+	defaultNotificationListLimit = 20
+	maxNotificationListLimit     = 100
+)
+
+// notificationMetrics counts sent and failed notifications by kinds, e.g. `new_report.sent`
+var notificationMetrics = expvar.NewMap("cds_notifications")
+
+// NotificationPayload is a notification in the outbox. It is sent to the users of external
+// user ids if there are any, or to all active users.
+type NotificationPayload struct {
+	Kind            string            `bson:"kind" json:"kind"`
+	Cohort          string            `bson:"cohort,omitempty" json:"cohort,omitempty"`
+	BatchID         string            `bson:"batch_id,omitempty" json:"batch_id,omitempty"`
+	POIID           string            `bson:"poi_id,omitempty" json:"poi_id,omitempty"`
+	ExternalUserIDs []string          `bson:"external_user_ids,omitempty" json:"external_user_ids,omitempty"`
+	Headings        map[string]string `bson:"headings" json:"headings"`
+	Contents        map[string]string `bson:"contents" json:"contents"`
+}
+
+// NotificationData is the variables of notification templates
+type NotificationData struct {
+	// Cohort is the name of the cohort
+	Cohort string
+	// Date is the date of the reports
+	Date string
+	// TopSymptom is the symptom which rises the most on the date, if any
+	TopSymptom string
+	// Symptoms are the symptoms of alerts ordered by significance
+	Symptoms []string
+	// Score and PreviousScore are the current and last notified community scores of a POI
+	Score         float64
+	PreviousScore float64
+}
+
+// SetNotificationTemplates sets the templates of notifications. A template named by the kind and
+// a cohort, e.g. `new_report.berkeley`, overrides the template of the kind for the cohort. Every
+// cohort must have a valid template of each kind, so it is called after the cohorts are set. The
+// template of POI score changes is checked when the notifications are enabled.
+func (cds *CDS) SetNotificationTemplates(templates notification.Templates) error {
+	for _, kind := range []string{notificationNewReport, notificationSymptomAlert} {
+		for id := range cds.cohorts {
+			t, ok := templates.Lookup(kind+"."+id, kind)
+			if !ok {
+				return fmt.Errorf("no %s notification template for cohort %s", kind, id)
+			}
+			if _, err := t.Render(NotificationData{}); err != nil {
+				return fmt.Errorf("invalid %s notification template for cohort %s: %s", kind, id, err)
+			}
+		}
+	}
+
+	cds.notificationTemplates = templates
+	return nil
+}
+
+// renderNotification renders the notification of a kind for a report batch of a cohort
+func (cds *CDS) renderNotification(kind string, cohort Cohort, batchID string, data NotificationData) (NotificationPayload, error) {
+	t, ok := cds.notificationTemplates.Lookup(kind+"."+cohort.ID, kind)
+	if !ok {
+		return NotificationPayload{}, fmt.Errorf("no %s notification template for cohort %s", kind, cohort.ID)
+	}
+
+	n, err := t.Render(data)
+	if err != nil {
+		return NotificationPayload{}, err
+	}
+
+	return NotificationPayload{
+		Kind:     kind,
+		Cohort:   cohort.ID,
+		BatchID:  batchID,
+		Headings: n.Headings,
+		Contents: n.Contents,
+	}, nil
+}
+
+// announceBatch enqueues the notification of a report batch. The symptoms of alerts, which
+// are ordered by significance, are announced instead of the new report if there are any.
+func (cds *CDS) announceBatch(ctx context.Context, batch store.SymptomReportBatch, alerts []store.SymptomAlert) error {
+	cohort, err := cds.lookupCohort(batch.Cohort)
+	if err != nil {
+		return err
+	}
+
+	kind := notificationNewReport
+	data := NotificationData{Cohort: cohort.Name}
+	for _, date := range batch.Dates {
+		if date > data.Date {
+			data.Date = date
+		}
+	}
+	if len(alerts) > 0 {
+		kind = notificationSymptomAlert
+		data.Date = alerts[0].Date
+		for _, a := range alerts {
+			data.Symptoms = append(data.Symptoms, a.Symptom)
+		}
+	}
+
+	// the notification is still sent without the top symptom
+	data.TopSymptom, err = cds.topRisingSymptom(ctx, cohort.ID, data.Date)
+	if err != nil {
+		log.WithField("prefix", "notification").WithField("batch_id", batch.ID.Hex()).
+			WithError(err).Warn("fail to find the top rising symptom")
+	}
+
+	payload, err := cds.renderNotification(kind, cohort, batch.ID.Hex(), data)
+	if err != nil {
+		return err
+	}
+	// a batch is announced once, so a pending notification of the batch is replaced
+	return cds.enqueueNotification(ctx, fmt.Sprintf("%s:%s", OutboxKindNotification, payload.BatchID), payload)
+}
+
+// topRisingSymptom returns the symptom of a cohort whose count on a date increases the most from
+// the previous reported day in the week before. Counts under the minimum contributors are ignored.
+func (cds *CDS) topRisingSymptom(ctx context.Context, cohort, date string) (string, error) {
+	day, err := time.Parse(store.SymptomCheckinDateLayout, date)
+	if err != nil {
+		return "", err
+	}
+
+	series, err := cds.dataStorePool.Community().GetSymptomTimeseries(ctx, cohort,
+		day.AddDate(0, 0, -7).Format(store.SymptomCheckinDateLayout), date)
+	if err != nil {
+		return "", err
+	}
+
+	top, topIncrease := "", 0
+	for symptom, buckets := range series {
+		n := len(buckets)
+		if n < 2 || buckets[n-1].Name != date || buckets[n-1].Value < cds.minContributors {
+			continue
+		}
+
+		increase := buckets[n-1].Value - buckets[n-2].Value
+		if increase > topIncrease || (increase == topIncrease && increase > 0 && symptom < top) {
+			top, topIncrease = symptom, increase
+		}
+	}
+	return top, nil
+}
+
+// enqueueNotification puts a notification into the outbox. A pending notification of the same
+// deduplication key is replaced.
+func (cds *CDS) enqueueNotification(ctx context.Context, dedupKey string, payload NotificationPayload) error {
+	msg, err := store.NewOutboxMessage(OutboxKindNotification, "", dedupKey, payload)
+	if err != nil {
+		return err
+	}
+
+	return cds.dataStorePool.Outbox().Enqueue(ctx, msg)
+}
+
+// NotificationWorker returns the worker which delivers notifications in the outbox
+func (cds *CDS) NotificationWorker() *outbox.Worker {
+	return outbox.NewWorker(cds.dataStorePool.Outbox(), OutboxKindNotification, cds.deliverNotification)
+}
+
+// deliverNotification sends a notification in the outbox
+func (cds *CDS) deliverNotification(ctx context.Context, msg *store.OutboxMessage) error {
+	var payload NotificationPayload
+	if err := msg.DecodePayload(&payload); err != nil {
+		return outbox.Permanent(err)
+	}
+
+	return cds.notify(payload)
+}
+
+// notify sends a notification to its users, or to all active users. A failure is logged and counted.
+func (cds *CDS) notify(payload NotificationPayload) error {
+	var err error
+	if len(payload.ExternalUserIDs) > 0 {
+		err = cds.notifier.NotifyUsers(payload.ExternalUserIDs, payload.Headings, payload.Contents)
+	} else {
+		err = cds.notifier.NotifyActiveUsers(payload.Headings, payload.Contents)
+	}
+	if err != nil {
+		notificationMetrics.Add(payload.Kind+".failed", 1)
+		log.WithField("prefix", "notification").WithField("kind", payload.Kind).WithField("cohort", payload.Cohort).
+			WithError(err).Error("fail to send notification")
+		return err
+	}
+
+	notificationMetrics.Add(payload.Kind+".sent", 1)
+	return nil
+}
+
+type outboxNotification struct {
+	store.OutboxMessage
+	Notification NotificationPayload `json:"notification"`
+}
+
+// ListNotifications returns notifications in the outbox, latest first. They can be filtered
+// by the status, i.e. pending, delivered or failed.
+func (cds *CDS) ListNotifications(c *gin.Context) {
+	var params struct {
+		Status string `form:"status"`
+		Limit  int64  `form:"limit"`
+	}
+	if err := c.BindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	switch params.Status {
+	case "", store.OutboxStatusPending, store.OutboxStatusDelivered, store.OutboxStatusFailed:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid status"})
+		return
+	}
+	if params.Limit < 0 || params.Limit > maxNotificationListLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		return
+	}
+	if params.Limit == 0 {
+		params.Limit = defaultNotificationListLimit
+	}
+
+	messages, err := cds.dataStorePool.Outbox().List(c, OutboxKindNotification, params.Status, params.Limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	notifications := make([]outboxNotification, 0, len(messages))
+	for _, msg := range messages {
+		n := outboxNotification{OutboxMessage: msg}
+		if err := msg.DecodePayload(&n.Notification); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		notifications = append(notifications, n)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"notifications": notifications})
+}
@@ -0,0 +1,157 @@
+package cds
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitmark-inc/data-store/notification"
+	"github.com/bitmark-inc/data-store/outbox"
+	"github.com/bitmark-inc/data-store/store"
+)
+
+func testNotificationTemplates(t *testing.T) notification.Templates {
+	newReport, err := notification.ParseTemplate(notificationNewReport,
+		map[string]string{"en": "New Data Available"},
+		map[string]string{"en": "New reports of {{.Date}} for the {{.Cohort}}.", "zh-Hant": "{{.Cohort}}的{{.Date}}新資料"})
+	assert.NoError(t, err)
+	newDavisReport, err := notification.ParseTemplate(notificationNewReport+".davis",
+		map[string]string{"en": "New Davis Data Available"},
+		map[string]string{"en": "New reports of {{.Date}}."})
+	assert.NoError(t, err)
+	symptomAlert, err := notification.ParseTemplate(notificationSymptomAlert,
+		map[string]string{"en": "Symptom Trend Alert"},
+		map[string]string{"en": `Unusually many reports of {{join .Symptoms ", "}} on {{.Date}}.`})
+	assert.NoError(t, err)
+	poiScoreChange, err := notification.ParseTemplate(notificationPOIScoreChange,
+		map[string]string{"en": "Place Rating Changed"},
+		map[string]string{"en": `The rating changed from {{printf "%.1f" .PreviousScore}} to {{printf "%.1f" .Score}}.`})
+	assert.NoError(t, err)
+
+	return notification.Templates{
+		notificationNewReport:            newReport,
+		notificationNewReport + ".davis": newDavisReport,
+		notificationSymptomAlert:         symptomAlert,
+		notificationPOIScoreChange:       poiScoreChange,
+	}
+}
+
+func TestSetNotificationTemplates(t *testing.T) {
+	cds := New(nil, nil)
+	assert.NoError(t, cds.SetCohorts(testCohorts, "berkeley"))
+	templates := testNotificationTemplates(t)
+	assert.NoError(t, cds.SetNotificationTemplates(templates))
+
+	payload, err := cds.renderNotification(notificationNewReport, testCohorts[0], "batch",
+		NotificationData{Cohort: testCohorts[0].Name, Date: "2020-07-21"})
+	assert.NoError(t, err)
+	assert.Equal(t, NotificationPayload{
+		Kind:     notificationNewReport,
+		Cohort:   "berkeley",
+		BatchID:  "batch",
+		Headings: map[string]string{"en": "New Data Available"},
+		Contents: map[string]string{
+			"en":      "New reports of 2020-07-21 for the UC Berkeley Safe Campus Study.",
+			"zh-Hant": "UC Berkeley Safe Campus Study的2020-07-21新資料",
+		},
+	}, payload)
+
+	// a cohort template overrides the template of the kind
+	payload, err = cds.renderNotification(notificationNewReport, testCohorts[1], "batch", NotificationData{Date: "2020-07-21"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"en": "New Davis Data Available"}, payload.Headings)
+
+	payload, err = cds.renderNotification(notificationSymptomAlert, testCohorts[1], "batch",
+		NotificationData{Date: "2020-07-21", Symptoms: []string{"Cough", "Fever"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "Unusually many reports of Cough, Fever on 2020-07-21.", payload.Contents["en"])
+
+	// the template of POI score changes is only required when the notifications are enabled
+	delete(templates, notificationPOIScoreChange)
+	assert.NoError(t, cds.SetNotificationTemplates(templates))
+
+	delete(templates, notificationSymptomAlert)
+	assert.Error(t, cds.SetNotificationTemplates(templates))
+
+	invalid, err := notification.ParseTemplate(notificationSymptomAlert, nil, map[string]string{"en": "{{.Unknown}}"})
+	assert.NoError(t, err)
+	templates[notificationSymptomAlert] = invalid
+	assert.Error(t, cds.SetNotificationTemplates(templates))
+}
+
+func TestShippedNotificationTemplates(t *testing.T) {
+	templates, err := notification.LoadTemplates("../commands/cds/notification_templates")
+	assert.NoError(t, err)
+
+	cds := New(nil, nil)
+	assert.NoError(t, cds.SetCohorts(testCohorts, "berkeley"))
+	assert.NoError(t, cds.SetNotificationTemplates(templates))
+	assert.NoError(t, cds.SetPOINotification(POINotificationConfig{Enabled: true, Threshold: 0.5}))
+
+	payload, err := cds.renderNotification(notificationNewReport, testCohorts[0], "batch",
+		NotificationData{Cohort: testCohorts[0].Name, Date: "2020-07-21", TopSymptom: "Cough"})
+	assert.NoError(t, err)
+	assert.Equal(t, "New Data Available", payload.Headings["en"])
+	assert.NotEmpty(t, payload.Contents["zh-Hant"])
+}
+
+func TestDeliverNotification(t *testing.T) {
+	recorder := notification.NewRecorder()
+	cds := New(nil, recorder)
+	assert.NoError(t, cds.SetCohorts(testCohorts, "berkeley"))
+	assert.NoError(t, cds.SetNotificationTemplates(testNotificationTemplates(t)))
+	payload, err := cds.renderNotification(notificationNewReport, testCohorts[0], "batch", NotificationData{})
+	assert.NoError(t, err)
+
+	msg, err := store.NewOutboxMessage(OutboxKindNotification, "", "notification:batch", payload)
+	assert.NoError(t, err)
+
+	sent := notificationMetrics.Get(notificationNewReport + ".sent")
+	assert.NoError(t, cds.deliverNotification(context.Background(), &msg))
+	assert.Equal(t, []notification.Notification{{
+		Headings: payload.Headings,
+		Contents: payload.Contents,
+	}}, recorder.Notifications())
+	assert.NotEqual(t, sent, notificationMetrics.Get(notificationNewReport+".sent"))
+
+	// a failed notification is retried by the worker
+	recorder.Err = errors.New("unavailable")
+	err = cds.deliverNotification(context.Background(), &msg)
+	assert.Error(t, err)
+	assert.False(t, outbox.IsPermanent(err))
+	assert.Len(t, recorder.Notifications(), 1)
+
+	// a malformed payload is never delivered
+	err = cds.deliverNotification(context.Background(), &store.OutboxMessage{Kind: OutboxKindNotification, Payload: []byte{0}})
+	assert.True(t, outbox.IsPermanent(err))
+}
+
+func TestDeliverTargetedNotification(t *testing.T) {
+	recorder := notification.NewRecorder()
+	cds := New(nil, recorder)
+	payload := NotificationPayload{
+		Kind:            notificationPOIScoreChange,
+		POIID:           "poi",
+		ExternalUserIDs: []string{"user1", "user2"},
+		Contents:        map[string]string{"en": "The rating changed"},
+	}
+
+	msg, err := store.NewOutboxMessage(OutboxKindNotification, "", "notification:poi", payload)
+	assert.NoError(t, err)
+	assert.NoError(t, cds.deliverNotification(context.Background(), &msg))
+	assert.Equal(t, []notification.Notification{{
+		ExternalUserIDs: []string{"user1", "user2"},
+		Contents:        payload.Contents,
+	}}, recorder.Notifications())
+}
+
+func TestNotifyMetrics(t *testing.T) {
+	recorder := notification.NewRecorder()
+	recorder.Err = errors.New("unavailable")
+	cds := New(nil, recorder)
+
+	assert.Error(t, cds.notify(NotificationPayload{Kind: notificationSymptomAlert, Cohort: "berkeley"}))
+	assert.Equal(t, "1", notificationMetrics.Get(notificationSymptomAlert+".failed").String())
+}
@@ -0,0 +1,266 @@
+package cds
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bitmark-inc/data-store/store"
+)
+
+const (
+	defaultNearbyRadius = 1000
+	maxNearbyRadius     = 50000
+
+	defaultRankingLimit = 10
+	maxRankingLimit     = 100
+	defaultTrendingDays = 7
+)
+
+type poiLocation struct {
+	Lat *float64 `json:"lat" form:"lat" binding:"required"`
+	Lng *float64 `json:"lng" form:"lng" binding:"required"`
+}
+
+func (l poiLocation) valid() bool {
+	return *l.Lat >= -90 && *l.Lat <= 90 && *l.Lng >= -180 && *l.Lng <= 180
+}
+
+type nearbyPOI struct {
+	store.POI
+	Summary *store.POISummarizedRating `json:"summary"`
+}
+
+// AddPOI creates or updates a community POI. It is restricted to admin accounts since a POI
+// is shared by all accounts.
+func (cds *CDS) AddPOI(c *gin.Context) {
+	poiID := c.Param("poi_id")
+
+	var params struct {
+		Name     string      `json:"name" binding:"required"`
+		Category string      `json:"category"`
+		Location poiLocation `json:"location" binding:"required"`
+	}
+
+	if err := c.BindJSON(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !params.Location.valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid location"})
+		return
+	}
+
+	err := cds.dataStorePool.Community().AddPOI(c, store.POI{
+		ID:       poiID,
+		Name:     params.Name,
+		Category: params.Category,
+		Location: store.NewGeoJSONPoint(*params.Location.Lat, *params.Location.Lng),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"result": "ok"})
+}
+
+func (cds *CDS) GetNearbyPOIs(c *gin.Context) {
+	var params struct {
+		poiLocation
+		Radius int `form:"radius"`
+	}
+
+	if err := c.BindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !params.valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid location"})
+		return
+	}
+
+	radius := defaultNearbyRadius
+	if params.Radius != 0 {
+		radius = params.Radius
+	}
+	if radius < 0 || radius > maxNearbyRadius {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid radius"})
+		return
+	}
+
+	pois, err := cds.dataStorePool.Community().GetNearbyPOIs(c, *params.Lat, *params.Lng, radius)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	results := make([]nearbyPOI, 0, len(pois))
+	if len(pois) > 0 {
+		poiIDs := make([]string, 0, len(pois))
+		for _, p := range pois {
+			poiIDs = append(poiIDs, p.ID)
+		}
+
+		summaries, err := cds.dataStorePool.Community().GetPOISummarizedRatings(c, poiIDs, store.POISummaryOption{
+			MinContributors: cds.minContributors,
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if cds.privacy.Enabled() {
+			if err := cds.releasePOISummaries(c, summaries, false); err != nil {
+				writePrivacyError(c, err)
+				return
+			}
+		}
+
+		for _, p := range pois {
+			r := nearbyPOI{POI: p}
+			if summary, ok := summaries[p.ID]; ok {
+				r.Summary = &summary
+			}
+			results = append(results, r)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"pois": results})
+}
+
+type poiRankingQueryParams struct {
+	Key      string   `form:"key" binding:"required"`
+	Limit    int64    `form:"limit"`
+	MinCount int      `form:"min_count"`
+	Lat      *float64 `form:"lat"`
+	Lng      *float64 `form:"lng"`
+	Radius   int      `form:"radius"`
+}
+
+// option converts query parameters into a ranking option. A geo radius is applied only
+// when both lat and lng are given. POIs rated by less than minContributors accounts are
+// never ranked.
+func (p poiRankingQueryParams) option(minContributors int) (store.POIRankingOption, error) {
+	option := store.POIRankingOption{
+		Key:      p.Key,
+		Limit:    defaultRankingLimit,
+		MinCount: p.MinCount,
+	}
+	if option.MinCount < minContributors {
+		option.MinCount = minContributors
+	}
+
+	if !store.ValidRatingKey(p.Key) {
+		return option, fmt.Errorf("invalid rating key")
+	}
+
+	if p.Limit != 0 {
+		option.Limit = p.Limit
+	}
+	if option.Limit < 0 || option.Limit > maxRankingLimit {
+		return option, fmt.Errorf("invalid limit")
+	}
+
+	if p.Lat != nil || p.Lng != nil {
+		location := poiLocation{Lat: p.Lat, Lng: p.Lng}
+		if p.Lat == nil || p.Lng == nil || !location.valid() {
+			return option, fmt.Errorf("invalid location")
+		}
+
+		option.Radius = defaultNearbyRadius
+		if p.Radius != 0 {
+			option.Radius = p.Radius
+		}
+		if option.Radius < 0 || option.Radius > maxNearbyRadius {
+			return option, fmt.Errorf("invalid radius")
+		}
+
+		point := store.NewGeoJSONPoint(*p.Lat, *p.Lng)
+		option.Near = &point
+	}
+
+	return option, nil
+}
+
+// rankingsAvailable reports whether POI rankings can be published. Rankings are ordered by exact
+// scores, so they are not available when statistics are noised for differential privacy. The error
+// response is written if it returns false.
+func (cds *CDS) rankingsAvailable(c *gin.Context) bool {
+	if cds.privacy.Enabled() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "rankings are not available when differential privacy is enabled"})
+		return false
+	}
+	return true
+}
+
+func (cds *CDS) GetTopPOIs(c *gin.Context) {
+	if !cds.rankingsAvailable(c) {
+		return
+	}
+
+	var params poiRankingQueryParams
+	if err := c.BindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	option, err := params.option(cds.minContributors)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rankings, err := cds.dataStorePool.Community().GetTopPOIs(c, option)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"pois": rankings})
+}
+
+func (cds *CDS) GetTrendingPOIs(c *gin.Context) {
+	if !cds.rankingsAvailable(c) {
+		return
+	}
+
+	var params struct {
+		poiRankingQueryParams
+		Days int `form:"days"`
+	}
+	if err := c.BindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	option, err := params.option(cds.minContributors)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if option.Near != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "location is not supported for trending POIs"})
+		return
+	}
+
+	days := defaultTrendingDays
+	if params.Days != 0 {
+		days = params.Days
+	}
+	if days < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid days"})
+		return
+	}
+
+	rankings, err := cds.dataStorePool.Community().GetTrendingPOIs(c, time.Duration(days)*24*time.Hour, option)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"pois": rankings})
+}
@@ -0,0 +1,259 @@
+package cds
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/bitmark-inc/data-store/notification"
+	"github.com/bitmark-inc/data-store/outbox"
+	"github.com/bitmark-inc/data-store/store"
+)
+
+// POINotificationConfig configures the notifications to accounts when the community score
+// of a POI they rated changes significantly
+type POINotificationConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Threshold is the change of the average rating from the last notified score which
+	// raters are notified of
+	Threshold float64 `mapstructure:"threshold"`
+	// Interval is the minimum interval between two notifications to an account
+	Interval time.Duration `mapstructure:"interval"`
+}
+
+// Validate checks whether score changes can be notified with the configuration
+func (c POINotificationConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Threshold <= 0 {
+		return fmt.Errorf("threshold must be positive")
+	}
+	if c.Interval < 0 {
+		return fmt.Errorf("interval must not be negative")
+	}
+	return nil
+}
+
+// OutboxKindPOIScoreCheck is the kind of outbox messages which check the community scores of rated POIs
+const OutboxKindPOIScoreCheck = "poi_score_check"
+
+// poiScoreCheck is the payload of a POI score check in the outbox
+type poiScoreCheck struct {
+	POIID string `json:"poi_id"`
+}
+
+// SetPOINotification makes each POI rating trigger the check of the community score of the POI.
+// The template of the notifications is required when it is enabled, so it is called after the
+// notification templates are set.
+func (cds *CDS) SetPOINotification(config POINotificationConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	if config.Enabled {
+		t, ok := cds.notificationTemplates.Lookup(notificationPOIScoreChange)
+		if !ok {
+			return fmt.Errorf("no %s notification template", notificationPOIScoreChange)
+		}
+		if _, err := t.Render(NotificationData{}); err != nil {
+			return fmt.Errorf("invalid %s notification template: %s", notificationPOIScoreChange, err)
+		}
+	}
+
+	cds.poiNotification = config
+	return nil
+}
+
+// triggerPOIScoreCheck puts the check of the score of a rated POI into the outbox, so that it
+// survives restarts. Checks of a POI are merged while pending.
+func (cds *CDS) triggerPOIScoreCheck(ctx context.Context, poiID string) error {
+	if !cds.poiNotification.Enabled {
+		return nil
+	}
+
+	msg, err := store.NewOutboxMessage(OutboxKindPOIScoreCheck, "",
+		fmt.Sprintf("%s:%s", OutboxKindPOIScoreCheck, poiID), poiScoreCheck{POIID: poiID})
+	if err != nil {
+		return err
+	}
+
+	return cds.dataStorePool.Outbox().Enqueue(ctx, msg)
+}
+
+// POIScoreCheckWorker returns the worker which checks the scores of rated POIs in the outbox
+func (cds *CDS) POIScoreCheckWorker() *outbox.Worker {
+	return outbox.NewWorker(cds.dataStorePool.Outbox(), OutboxKindPOIScoreCheck, cds.checkRatedPOIScore)
+}
+
+// checkRatedPOIScore checks the score of the POI of an outbox message
+func (cds *CDS) checkRatedPOIScore(ctx context.Context, msg *store.OutboxMessage) error {
+	var check poiScoreCheck
+	if err := msg.DecodePayload(&check); err != nil {
+		return outbox.Permanent(err)
+	}
+	if check.POIID == "" {
+		return outbox.Permanent(fmt.Errorf("no poi id"))
+	}
+
+	return cds.checkPOIScore(ctx, check.POIID)
+}
+
+// checkPOIScore compares the community score of a POI with its snapshot, and notifies its raters
+// if the score changes by the threshold. The first snapshot of a POI is only taken as the baseline.
+// Scores of POIs rated by too few accounts are not checked. The snapshot is replaced only after the
+// notifications are queued, so that a failed check is retried from the same snapshot.
+// When differential privacy is enabled, the noised score released for the current version of the
+// ratings is compared and notified, so that checks spend no more budget than queries of the POI.
+func (cds *CDS) checkPOIScore(ctx context.Context, poiID string) error {
+	community := cds.dataStorePool.Community()
+	summaries, err := community.GetPOISummarizedRatings(ctx, []string{poiID},
+		store.POISummaryOption{MinContributors: cds.minContributors})
+	if err != nil {
+		return err
+	}
+	summary, ok := summaries[poiID]
+	if !ok || summary.Suppressed {
+		return nil
+	}
+
+	if cds.privacy.Enabled() {
+		// the noised score is the release of the current ratings, which is shared with queries
+		err := cds.releasePOISummaries(ctx, summaries, false)
+		if err == store.ErrPrivacyBudgetExhausted {
+			log.WithField("prefix", "poi_notification").WithField("poi_id", poiID).
+				Warn("skip poi score check since the privacy budget is exhausted")
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		summary = summaries[poiID]
+		if len(summary.Ratings) == 0 {
+			return nil
+		}
+	}
+
+	previous, err := community.GetPOIScoreSnapshot(ctx, poiID)
+	if err != nil {
+		return err
+	}
+	if previous != nil {
+		if math.Abs(summary.AverageRating-previous.Score) < cds.poiNotification.Threshold {
+			return nil
+		}
+		if err := cds.announcePOIScoreChange(ctx, *previous, summary.AverageRating); err != nil {
+			return err
+		}
+	}
+
+	return community.SetPOIScoreSnapshot(ctx, store.POIScoreSnapshot{
+		ID:          poiID,
+		Score:       summary.AverageRating,
+		RatingCount: summary.RatingCount,
+	})
+}
+
+// announcePOIScoreChange enqueues notifications of a score change from a snapshot to the raters of
+// a POI who opt in. Raters notified within the interval are skipped. The quota of raters is given
+// back if their notification fails to be queued, and a retry of the same change replaces the
+// notifications still pending.
+func (cds *CDS) announcePOIScoreChange(ctx context.Context, previous store.POIScoreSnapshot, score float64) error {
+	community := cds.dataStorePool.Community()
+	subscribers, err := community.GetPOIScoreSubscribers(ctx, previous.ID)
+	if err != nil {
+		return err
+	}
+	if len(subscribers) == 0 {
+		return nil
+	}
+
+	t, ok := cds.notificationTemplates.Lookup(notificationPOIScoreChange)
+	if !ok {
+		return fmt.Errorf("no %s notification template", notificationPOIScoreChange)
+	}
+	n, err := t.Render(NotificationData{Score: score, PreviousScore: previous.Score})
+	if err != nil {
+		return err
+	}
+
+	// a notification is sent to at most a limited number of users
+	notified := 0
+	for i := 0; i < len(subscribers); i += notification.MaxExternalUserIDs {
+		end := i + notification.MaxExternalUserIDs
+		if end > len(subscribers) {
+			end = len(subscribers)
+		}
+
+		quota, err := community.ClaimNotificationQuota(ctx, subscribers[i:end], cds.poiNotification.Interval)
+		if err != nil {
+			return err
+		}
+		if len(quota.ExternalUserIDs) == 0 {
+			continue
+		}
+
+		payload := NotificationPayload{
+			Kind:            notificationPOIScoreChange,
+			POIID:           previous.ID,
+			ExternalUserIDs: quota.ExternalUserIDs,
+			Headings:        n.Headings,
+			Contents:        n.Contents,
+		}
+		dedupKey := fmt.Sprintf("%s:%s:%d:%d:%d", OutboxKindNotification, previous.ID, previous.RatingCount, previous.Timestamp, i)
+		if err := cds.enqueueNotification(ctx, dedupKey, payload); err != nil {
+			if releaseErr := community.ReleaseNotificationQuota(ctx, *quota); releaseErr != nil {
+				log.WithField("prefix", "poi_notification").WithField("poi_id", previous.ID).
+					WithError(releaseErr).Error("fail to release notification quota")
+			}
+			return err
+		}
+		notified += len(quota.ExternalUserIDs)
+	}
+
+	log.WithField("prefix", "poi_notification").WithField("poi_id", previous.ID).
+		WithField("users", notified).Info("poi score change announced")
+	return nil
+}
+
+// SetNotificationPreference opts the account in or out of notifications of POI score changes.
+// The external user id in the response is registered by the app to receive the notifications.
+func (cds *CDS) SetNotificationPreference(c *gin.Context) {
+	accountNumber := c.GetString("account_number")
+
+	var params struct {
+		POIScoreChanges *bool `json:"poi_score_changes" binding:"required"`
+	}
+
+	if err := c.BindJSON(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	preference, err := cds.dataStorePool.Community().SetNotificationPreference(c, accountNumber, *params.POIScoreChanges)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, preference)
+}
+
+// GetNotificationPreference returns the notification preference of the account
+func (cds *CDS) GetNotificationPreference(c *gin.Context) {
+	accountNumber := c.GetString("account_number")
+
+	preference, err := cds.dataStorePool.Community().GetNotificationPreference(c, accountNumber)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, preference)
+}
@@ -0,0 +1,265 @@
+package cds
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitmark-inc/data-store/notification"
+	"github.com/bitmark-inc/data-store/outbox"
+	"github.com/bitmark-inc/data-store/privacy"
+	"github.com/bitmark-inc/data-store/rating"
+	"github.com/bitmark-inc/data-store/store"
+)
+
+func TestPOINotificationConfig(t *testing.T) {
+	assert.NoError(t, POINotificationConfig{}.Validate())
+	assert.NoError(t, POINotificationConfig{Enabled: true, Threshold: 0.5, Interval: 24 * time.Hour}.Validate())
+	assert.Error(t, POINotificationConfig{Enabled: true}.Validate())
+	assert.Error(t, POINotificationConfig{Enabled: true, Threshold: 0.5, Interval: -time.Hour}.Validate())
+}
+
+func TestSetPOINotification(t *testing.T) {
+	cds := New(nil, nil)
+	assert.NoError(t, cds.SetCohorts(testCohorts, "berkeley"))
+	templates := testNotificationTemplates(t)
+	delete(templates, notificationPOIScoreChange)
+	assert.NoError(t, cds.SetNotificationTemplates(templates))
+	config := POINotificationConfig{Enabled: true, Threshold: 0.5}
+
+	// the template is only required when the notifications are enabled
+	assert.NoError(t, cds.SetPOINotification(POINotificationConfig{}))
+	assert.Error(t, cds.SetPOINotification(config))
+
+	invalid, err := notification.ParseTemplate(notificationPOIScoreChange, nil, map[string]string{"en": "{{.Unknown}}"})
+	assert.NoError(t, err)
+	templates[notificationPOIScoreChange] = invalid
+	assert.NoError(t, cds.SetNotificationTemplates(templates))
+	assert.Error(t, cds.SetPOINotification(config))
+
+	assert.NoError(t, cds.SetNotificationTemplates(testNotificationTemplates(t)))
+	assert.NoError(t, cds.SetPOINotification(config))
+}
+
+func TestTriggerPOIScoreCheckDisabled(t *testing.T) {
+	cds := New(nil, nil)
+	assert.NoError(t, cds.SetPOINotification(POINotificationConfig{}))
+	// no check is queued when it is disabled
+	assert.NoError(t, cds.triggerPOIScoreCheck(context.Background(), "poi"))
+}
+
+func TestCheckRatedPOIScoreMalformed(t *testing.T) {
+	cds := New(nil, nil)
+	err := cds.checkRatedPOIScore(context.Background(), &store.OutboxMessage{Kind: OutboxKindPOIScoreCheck, Payload: []byte{0}})
+	assert.True(t, outbox.IsPermanent(err))
+
+	msg, err := store.NewOutboxMessage(OutboxKindPOIScoreCheck, "", "", poiScoreCheck{})
+	assert.NoError(t, err)
+	assert.True(t, outbox.IsPermanent(cds.checkRatedPOIScore(context.Background(), &msg)))
+}
+
+// fakePOIScoreStore serves the community and outbox stores of POI score checks from memory
+type fakePOIScoreStore struct {
+	store.DataStorePool
+	store.CommunityDataStore
+	store.OutboxStore
+
+	summary     store.POISummarizedRating
+	snapshot    *store.POIScoreSnapshot
+	subscribers []string
+	enqueueErr  error
+
+	claimed  [][]string
+	released []store.NotificationQuota
+	enqueued []store.OutboxMessage
+	releases map[string]store.PrivacyRelease
+	spent    int
+}
+
+func (s *fakePOIScoreStore) Community() store.CommunityDataStore {
+	return s
+}
+
+func (s *fakePOIScoreStore) Outbox() store.OutboxStore {
+	return s
+}
+
+func (s *fakePOIScoreStore) GetPOISummarizedRatings(ctx context.Context, poiIDs []string, opts ...store.POISummaryOption) (map[string]store.POISummarizedRating, error) {
+	return map[string]store.POISummarizedRating{s.summary.ID: s.summary}, nil
+}
+
+func (s *fakePOIScoreStore) GetPOIScoreSnapshot(ctx context.Context, poiID string) (*store.POIScoreSnapshot, error) {
+	return s.snapshot, nil
+}
+
+func (s *fakePOIScoreStore) SetPOIScoreSnapshot(ctx context.Context, snapshot store.POIScoreSnapshot) error {
+	s.snapshot = &snapshot
+	return nil
+}
+
+func (s *fakePOIScoreStore) GetPOIScoreSubscribers(ctx context.Context, poiID string) ([]string, error) {
+	return s.subscribers, nil
+}
+
+func (s *fakePOIScoreStore) ClaimNotificationQuota(ctx context.Context, externalUserIDs []string, interval time.Duration) (*store.NotificationQuota, error) {
+	s.claimed = append(s.claimed, externalUserIDs)
+	return &store.NotificationQuota{ExternalUserIDs: externalUserIDs, ClaimedAt: 1}, nil
+}
+
+func (s *fakePOIScoreStore) ReleaseNotificationQuota(ctx context.Context, quota store.NotificationQuota) error {
+	s.released = append(s.released, quota)
+	return nil
+}
+
+func (s *fakePOIScoreStore) Enqueue(ctx context.Context, msg store.OutboxMessage) error {
+	if s.enqueueErr != nil {
+		return s.enqueueErr
+	}
+	s.enqueued = append(s.enqueued, msg)
+	return nil
+}
+
+func (s *fakePOIScoreStore) SpendPrivacyBudget(ctx context.Context, dataset string, epsilon, limit float64, period time.Duration) error {
+	s.spent++
+	return nil
+}
+
+func (s *fakePOIScoreStore) GetPrivacyReleases(ctx context.Context, dataset string, keys []string) (map[string]store.PrivacyRelease, error) {
+	return s.releases, nil
+}
+
+func (s *fakePOIScoreStore) SetPrivacyRelease(ctx context.Context, release store.PrivacyRelease) error {
+	if s.releases == nil {
+		s.releases = map[string]store.PrivacyRelease{}
+	}
+	s.releases[release.Key] = release
+	return nil
+}
+
+func newTestPOIScoreCDS(t *testing.T, fake *fakePOIScoreStore) *CDS {
+	cds := New(fake, nil)
+	assert.NoError(t, cds.SetCohorts(testCohorts, "berkeley"))
+	assert.NoError(t, cds.SetNotificationTemplates(testNotificationTemplates(t)))
+	assert.NoError(t, cds.SetPOINotification(POINotificationConfig{Enabled: true, Threshold: 0.5, Interval: time.Hour}))
+	return cds
+}
+
+func TestCheckPOIScore(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakePOIScoreStore{
+		summary:     store.POISummarizedRating{ID: "poi", RatingCount: 3, AverageRating: 3},
+		subscribers: []string{"user-1", "user-2"},
+	}
+	cds := newTestPOIScoreCDS(t, fake)
+
+	// the first snapshot is the baseline
+	assert.NoError(t, cds.checkPOIScore(ctx, "poi"))
+	assert.Equal(t, &store.POIScoreSnapshot{ID: "poi", Score: 3, RatingCount: 3}, fake.snapshot)
+	assert.Empty(t, fake.claimed)
+	assert.Empty(t, fake.enqueued)
+
+	// changes below the threshold are neither notified nor kept
+	fake.summary.RatingCount, fake.summary.AverageRating = 4, 3.4
+	assert.NoError(t, cds.checkPOIScore(ctx, "poi"))
+	assert.Equal(t, 3.0, fake.snapshot.Score)
+	assert.Empty(t, fake.enqueued)
+
+	// the snapshot is kept when the notification fails to be queued, and the quota is released
+	fake.summary.RatingCount, fake.summary.AverageRating = 5, 4
+	fake.enqueueErr = fmt.Errorf("outbox unavailable")
+	assert.Error(t, cds.checkPOIScore(ctx, "poi"))
+	assert.Equal(t, &store.POIScoreSnapshot{ID: "poi", Score: 3, RatingCount: 3}, fake.snapshot)
+	assert.Equal(t, []store.NotificationQuota{{ExternalUserIDs: []string{"user-1", "user-2"}, ClaimedAt: 1}}, fake.released)
+	assert.Empty(t, fake.enqueued)
+
+	// the retry notifies the change from the same snapshot, then replaces the snapshot
+	fake.enqueueErr = nil
+	assert.NoError(t, cds.checkPOIScore(ctx, "poi"))
+	assert.Len(t, fake.enqueued, 1)
+	msg := fake.enqueued[0]
+	assert.Equal(t, OutboxKindNotification, msg.Kind)
+	assert.Equal(t, fmt.Sprintf("%s:poi:3:0:0", OutboxKindNotification), msg.DedupKey)
+
+	var payload NotificationPayload
+	assert.NoError(t, msg.DecodePayload(&payload))
+	assert.Equal(t, notificationPOIScoreChange, payload.Kind)
+	assert.Equal(t, "poi", payload.POIID)
+	assert.Equal(t, []string{"user-1", "user-2"}, payload.ExternalUserIDs)
+	assert.Equal(t, "The rating changed from 3.0 to 4.0.", payload.Contents["en"])
+
+	assert.Equal(t, 4.0, fake.snapshot.Score)
+	assert.Equal(t, int64(5), fake.snapshot.RatingCount)
+}
+
+func TestCheckPOIScoreSuppressed(t *testing.T) {
+	fake := &fakePOIScoreStore{summary: store.POISummarizedRating{ID: "poi", Suppressed: true}}
+	cds := newTestPOIScoreCDS(t, fake)
+
+	assert.NoError(t, cds.checkPOIScore(context.Background(), "poi"))
+	assert.Nil(t, fake.snapshot)
+}
+
+func TestAnnouncePOIScoreChangeChunks(t *testing.T) {
+	subscribers := make([]string, notification.MaxExternalUserIDs+1)
+	for i := range subscribers {
+		subscribers[i] = fmt.Sprintf("user-%d", i)
+	}
+	fake := &fakePOIScoreStore{subscribers: subscribers}
+	cds := newTestPOIScoreCDS(t, fake)
+
+	previous := store.POIScoreSnapshot{ID: "poi", Score: 3, RatingCount: 3, Timestamp: 100}
+	assert.NoError(t, cds.announcePOIScoreChange(context.Background(), previous, 4))
+
+	assert.Len(t, fake.claimed, 2)
+	assert.Len(t, fake.claimed[0], notification.MaxExternalUserIDs)
+	assert.Equal(t, subscribers[notification.MaxExternalUserIDs:], fake.claimed[1])
+
+	assert.Len(t, fake.enqueued, 2)
+	assert.Equal(t, fmt.Sprintf("%s:poi:3:100:0", OutboxKindNotification), fake.enqueued[0].DedupKey)
+	assert.Equal(t, fmt.Sprintf("%s:poi:3:100:%d", OutboxKindNotification, notification.MaxExternalUserIDs), fake.enqueued[1].DedupKey)
+	for i, msg := range fake.enqueued {
+		var payload NotificationPayload
+		assert.NoError(t, msg.DecodePayload(&payload))
+		assert.Equal(t, fake.claimed[i], payload.ExternalUserIDs)
+	}
+	assert.Empty(t, fake.released)
+}
+
+func TestAnnouncePOIScoreChangeEnqueueFailure(t *testing.T) {
+	fake := &fakePOIScoreStore{subscribers: []string{"user-1"}, enqueueErr: fmt.Errorf("outbox unavailable")}
+	cds := newTestPOIScoreCDS(t, fake)
+
+	previous := store.POIScoreSnapshot{ID: "poi", Score: 3, RatingCount: 3}
+	assert.Error(t, cds.announcePOIScoreChange(context.Background(), previous, 4))
+	assert.Equal(t, []store.NotificationQuota{{ExternalUserIDs: []string{"user-1"}, ClaimedAt: 1}}, fake.released)
+}
+
+func TestCheckPOIScoreWithDifferentialPrivacy(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakePOIScoreStore{summary: store.POISummarizedRating{
+		ID:            "poi",
+		RatingCount:   3,
+		LastUpdated:   100,
+		AverageRating: 3,
+		Ratings:       map[string]store.RatingInfo{"mask": {Score: 3, Counts: 3, Weight: 3}},
+	}}
+	cds := newTestPOIScoreCDS(t, fake)
+	cds.SetRatingSchema(rating.Schema{Keys: []rating.KeySchema{{Key: "mask", Min: 1, Max: 5}}})
+	assert.NoError(t, cds.SetDifferentialPrivacy(privacy.Config{Mechanism: privacy.Laplace, Epsilon: 1, Budget: 10}))
+
+	// the noised score of a version of the ratings is released once and reused by later checks
+	assert.NoError(t, cds.checkPOIScore(ctx, "poi"))
+	assert.Equal(t, 1, fake.spent)
+	baseline := fake.snapshot.Score
+	assert.NoError(t, cds.checkPOIScore(ctx, "poi"))
+	assert.Equal(t, 1, fake.spent)
+	assert.Equal(t, baseline, fake.snapshot.Score)
+
+	// a new version of the ratings is released again
+	fake.summary.RatingCount, fake.summary.LastUpdated = 4, 200
+	assert.NoError(t, cds.checkPOIScore(ctx, "poi"))
+	assert.Equal(t, 2, fake.spent)
+}
@@ -3,9 +3,12 @@ package cds
 import (
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/bitmark-inc/data-store/rating"
 	"github.com/bitmark-inc/data-store/store"
 	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
 )
 
 func (cds *CDS) SetPOIRating() gin.HandlerFunc {
@@ -22,27 +25,88 @@ func (cds *CDS) SetPOIRating() gin.HandlerFunc {
 			return
 		}
 
+		if poiID == rating.SchemaPath {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid poi id"})
+			return
+		}
+
+		if violations := cds.ratingSchema.Validate(params.Ratings); len(violations) > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ratings", "violations": violations})
+			return
+		}
+
 		err := cds.dataStorePool.Community().SetPOIRating(c, accountNumber, poiID, params.Ratings)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		if err := cds.triggerPOIScoreCheck(c, poiID); err != nil {
+			log.WithField("prefix", "poi_notification").WithField("poi_id", poiID).
+				WithError(err).Error("fail to trigger poi score check")
+		}
 
 		c.JSON(http.StatusOK, gin.H{"result": "ok"})
 	}
 }
 
+type poiSummaryQueryParams struct {
+	AsOf         int64    `form:"as_of"`
+	Since        int64    `form:"since"`
+	HalfLifeDays float64  `form:"half_life_days"`
+	Bayesian     bool     `form:"bayesian"`
+	PriorMean    *float64 `form:"prior_mean"`
+	PriorWeight  *float64 `form:"prior_weight"`
+	Distribution bool     `form:"distribution"`
+}
+
+func (p poiSummaryQueryParams) option(defaultPrior store.BayesianPrior) store.POISummaryOption {
+	option := store.POISummaryOption{
+		AsOf:         p.AsOf,
+		Since:        p.Since,
+		HalfLife:     time.Duration(p.HalfLifeDays * float64(24*time.Hour)),
+		Distribution: p.Distribution,
+	}
+
+	if p.Bayesian {
+		prior := defaultPrior
+		if p.PriorMean != nil {
+			prior.Mean = *p.PriorMean
+		}
+		if p.PriorWeight != nil {
+			prior.Weight = *p.PriorWeight
+		}
+		option.Prior = &prior
+	}
+
+	return option
+}
+
 func (cds *CDS) GetPOISummarizedRatings(c *gin.Context) {
+	var summaryParams poiSummaryQueryParams
+	if err := c.BindQuery(&summaryParams); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if summaryParams.HalfLifeDays < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "half_life_days must not be negative"})
+		return
+	}
+	if summaryParams.PriorWeight != nil && *summaryParams.PriorWeight < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "prior_weight must not be negative"})
+		return
+	}
+	option := summaryParams.option(cds.ratingPrior)
+	option.MinContributors = cds.minContributors
+
 	poiID := c.Param("poi_id")
-	var result map[string]store.POISummarizedRating
-	var err error
-	if poiID != "" {
-		result, err = cds.dataStorePool.Community().GetPOISummarizedRatings(c, []string{poiID})
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-	} else {
+	// the rating schema shares the route with POIs
+	if poiID == rating.SchemaPath {
+		cds.GetPOIRatingSchema(c)
+		return
+	}
+
+	poiIDs := []string{poiID}
+	if poiID == "" {
 		var params struct {
 			POIIDs string `form:"poi_ids" binding:"required"`
 		}
@@ -52,16 +116,32 @@ func (cds *CDS) GetPOISummarizedRatings(c *gin.Context) {
 			return
 		}
 
-		poiIDs := strings.Split(params.POIIDs, ",")
+		poiIDs = strings.Split(params.POIIDs, ",")
+	}
 
-		result, err = cds.dataStorePool.Community().GetPOISummarizedRatings(c, poiIDs)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
+	// noised summaries are released for the current ratings only, so that each version is noised once
+	if cds.privacy.Enabled() && (option.AsOf != 0 || option.Since != 0 || option.HalfLife != 0) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "as_of, since and half_life_days are not available when differential privacy is enabled"})
+		return
+	}
+
+	result, err := cds.dataStorePool.Community().GetPOISummarizedRatings(c, poiIDs, option)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
 	if len(result) == 0 {
 		result = map[string]store.POISummarizedRating{}
 	}
+	if cds.privacy.Enabled() {
+		if err := cds.releasePOISummaries(c, result, option.Distribution); err != nil {
+			writePrivacyError(c, err)
+			return
+		}
+	}
 	c.JSON(http.StatusOK, result)
 }
+
+func (cds *CDS) GetPOIRatingSchema(c *gin.Context) {
+	c.JSON(http.StatusOK, cds.ratingSchema)
+}
@@ -0,0 +1,59 @@
+package cds
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitmark-inc/data-store/privacy"
+	"github.com/bitmark-inc/data-store/rating"
+	"github.com/bitmark-inc/data-store/store"
+)
+
+func TestPOIRankingQueryParamsOption(t *testing.T) {
+	option, err := poiRankingQueryParams{Key: "mask"}.option(0)
+	assert.NoError(t, err)
+	assert.Equal(t, store.POIRankingOption{Key: "mask", Limit: defaultRankingLimit}, option)
+
+	lat, lng := 37.8696, -122.2593
+	option, err = poiRankingQueryParams{Key: "mask", Limit: 5, MinCount: 3, Lat: &lat, Lng: &lng}.option(0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), option.Limit)
+	assert.Equal(t, 3, option.MinCount)
+	assert.Equal(t, defaultNearbyRadius, option.Radius)
+	assert.Equal(t, []float64{lng, lat}, option.Near.Coordinates)
+
+	option, err = poiRankingQueryParams{Key: "mask", MinCount: 3}.option(5)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, option.MinCount)
+
+	_, err = poiRankingQueryParams{Key: "ratings.$where"}.option(0)
+	assert.Error(t, err)
+
+	_, err = poiRankingQueryParams{Key: "mask", Limit: maxRankingLimit + 1}.option(0)
+	assert.Error(t, err)
+
+	_, err = poiRankingQueryParams{Key: "mask", Lat: &lat}.option(0)
+	assert.Error(t, err)
+
+	_, err = poiRankingQueryParams{Key: "mask", Lat: &lat, Lng: &lng, Radius: maxNearbyRadius + 1}.option(0)
+	assert.Error(t, err)
+}
+
+func TestPOIRankingsWithDifferentialPrivacy(t *testing.T) {
+	cds := New(nil, nil)
+	cds.SetRatingSchema(rating.Schema{Keys: []rating.KeySchema{{Key: "mask", Min: 1, Max: 5}}})
+	assert.NoError(t, cds.SetDifferentialPrivacy(privacy.Config{Mechanism: privacy.Laplace, Epsilon: 1, Budget: 10}))
+	r := gin.New()
+	r.GET("/pois/top", cds.GetTopPOIs)
+	r.GET("/pois/trending", cds.GetTrendingPOIs)
+
+	for _, path := range []string{"/pois/top?key=mask", "/pois/trending?key=mask"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		assert.Equal(t, http.StatusForbidden, w.Code, path)
+	}
+}
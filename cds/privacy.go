@@ -0,0 +1,303 @@
+package cds
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/bitmark-inc/data-store/privacy"
+	"github.com/bitmark-inc/data-store/store"
+)
+
+// datasets whose privacy budgets are tracked separately
+const (
+	datasetPOIRatings     = "poi_ratings"
+	datasetSymptomReports = "symptom_reports"
+)
+
+// SetDifferentialPrivacy makes public community statistics noised by the configured mechanism.
+// Scores are only released for rating keys with bounds, so it is called after the rating schema
+// is set, and a schema without any bounded key is rejected.
+func (cds *CDS) SetDifferentialPrivacy(config privacy.Config) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	if config.Enabled() && !cds.ratingSchema.Bounded() {
+		return fmt.Errorf("differential privacy requires a rating schema with bounded keys")
+	}
+
+	cds.privacy = config
+	return nil
+}
+
+// spendPrivacyBudget spends the epsilon of a release from the budget of a dataset
+func (cds *CDS) spendPrivacyBudget(ctx context.Context, dataset string) error {
+	return cds.dataStorePool.Community().SpendPrivacyBudget(ctx, dataset,
+		cds.privacy.Epsilon, cds.privacy.Budget, cds.privacy.BudgetPeriod)
+}
+
+// writePrivacyError writes the error response of a failed release of noised statistics
+func writePrivacyError(c *gin.Context, err error) {
+	if err == store.ErrPrivacyBudgetExhausted {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// poiReleaseKey returns the key of the release of the summary of a POI
+func poiReleaseKey(poiID string, distribution bool) string {
+	if distribution {
+		return poiID + ":distribution"
+	}
+	return poiID
+}
+
+// poiSummaryVersion identifies the ratings a summary is computed from, which changes whenever
+// the POI is rated or a rating is removed
+func poiSummaryVersion(s store.POISummarizedRating) string {
+	return fmt.Sprintf("%d:%d", s.RatingCount, s.LastUpdated)
+}
+
+// releasePOISummaries replaces POI summaries with their noised releases. A summary is noised once
+// for each version of its ratings, and the release is reused until the ratings change, so that
+// repeated queries do not spend the privacy budget again. The budget is spent once for all the
+// summaries released together. Suppressed summaries are kept.
+func (cds *CDS) releasePOISummaries(ctx context.Context, summaries map[string]store.POISummarizedRating, distribution bool) error {
+	keys := make([]string, 0, len(summaries))
+	for id, s := range summaries {
+		if !s.Suppressed {
+			keys = append(keys, poiReleaseKey(id, distribution))
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	community := cds.dataStorePool.Community()
+	releases, err := community.GetPrivacyReleases(ctx, datasetPOIRatings, keys)
+	if err != nil {
+		return err
+	}
+
+	fresh := map[string]store.POISummarizedRating{}
+	versions := map[string]string{}
+	for id, s := range summaries {
+		if s.Suppressed {
+			continue
+		}
+
+		version := poiSummaryVersion(s)
+		if r, ok := releases[poiReleaseKey(id, distribution)]; ok && r.Version == version {
+			var noised store.POISummarizedRating
+			if err := r.DecodeValue(&noised); err != nil {
+				return err
+			}
+			noised.Noised = true
+			summaries[id] = noised
+			continue
+		}
+
+		fresh[id] = s
+		versions[id] = version
+	}
+	if len(fresh) == 0 {
+		return nil
+	}
+
+	if err := cds.spendPrivacyBudget(ctx, datasetPOIRatings); err != nil {
+		return err
+	}
+	cds.noisePOISummaries(fresh)
+
+	for id, noised := range fresh {
+		release, err := store.NewPrivacyRelease(datasetPOIRatings, poiReleaseKey(id, distribution), versions[id], noised)
+		if err != nil {
+			return err
+		}
+		if err := community.SetPrivacyRelease(ctx, release); err != nil {
+			return err
+		}
+		summaries[id] = noised
+	}
+	return nil
+}
+
+// symptomReportVersion identifies the content of a daily report
+func symptomReportVersion(report store.SymptomDailyReport) (string, error) {
+	data, err := bson.Marshal(report)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// releaseSymptomReports returns the noised releases of daily reports in the same order. A report
+// is noised once for each version of its content, and the release is reused until the report is
+// replaced, so that every statistic derived from the releases is free of further privacy loss.
+// The budget is spent once for all the reports released together.
+func (cds *CDS) releaseSymptomReports(ctx context.Context, reports []store.SymptomDailyReport) ([]store.SymptomDailyReport, error) {
+	released := make([]store.SymptomDailyReport, len(reports))
+	if len(reports) == 0 {
+		return released, nil
+	}
+
+	keys := make([]string, len(reports))
+	versions := make([]string, len(reports))
+	for i, report := range reports {
+		version, err := symptomReportVersion(report)
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = report.Cohort + "/" + report.Date
+		versions[i] = version
+	}
+
+	community := cds.dataStorePool.Community()
+	releases, err := community.GetPrivacyReleases(ctx, datasetSymptomReports, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	fresh := make([]int, 0)
+	for i, report := range reports {
+		if r, ok := releases[keys[i]]; ok && r.Version == versions[i] {
+			if err := r.DecodeValue(&released[i]); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		released[i] = report
+		released[i].Symptoms = append([]store.SymptomStats{}, report.Symptoms...)
+		fresh = append(fresh, i)
+	}
+	if len(fresh) == 0 {
+		return released, nil
+	}
+
+	if err := cds.spendPrivacyBudget(ctx, datasetSymptomReports); err != nil {
+		return nil, err
+	}
+
+	noised := make([]*store.SymptomDailyReport, 0, len(fresh))
+	for _, i := range fresh {
+		noised = append(noised, &released[i])
+	}
+	cds.noiseSymptomReports(noised...)
+
+	for _, i := range fresh {
+		release, err := store.NewPrivacyRelease(datasetSymptomReports, keys[i], versions[i], released[i])
+		if err != nil {
+			return nil, err
+		}
+		if err := community.SetPrivacyRelease(ctx, release); err != nil {
+			return nil, err
+		}
+	}
+	return released, nil
+}
+
+// noisePOISummaries perturbs counts and scores of POI summaries. Scores are derived from
+// noised sums and weights, and are only released for keys bounded by the rating schema.
+// Statistics which can not be derived from the noised ones, e.g. bayesian scores and
+// confidence intervals, are removed.
+func (cds *CDS) noisePOISummaries(summaries map[string]store.POISummarizedRating) {
+	statistics := 0
+	for _, s := range summaries {
+		if s.Suppressed {
+			continue
+		}
+		statistics++
+		for _, r := range s.Ratings {
+			// counts, weights and weighted sums, with the histogram if any
+			statistics += 3
+			if r.Histogram != nil {
+				statistics++
+			}
+		}
+	}
+	if statistics == 0 {
+		return
+	}
+	noise := privacy.NewNoise(cds.privacy).Split(statistics)
+
+	for id, s := range summaries {
+		if s.Suppressed {
+			continue
+		}
+
+		noised := store.POISummarizedRating{
+			ID:          s.ID,
+			RatingCount: int64(noise.Count(int(s.RatingCount))),
+			Ratings:     map[string]store.RatingInfo{},
+			Noised:      true,
+		}
+
+		scoreSum := 0.0
+		for key, r := range s.Ratings {
+			min, max, ok := cds.ratingSchema.Bounds(key)
+			if !ok {
+				continue
+			}
+
+			sensitivity := max
+			if -min > sensitivity {
+				sensitivity = -min
+			}
+
+			score := (min + max) / 2
+			weight := noise.Add(r.Weight, 1)
+			if weight > 0 {
+				score = noise.Add(r.Score*r.Weight, sensitivity) / weight
+			}
+			if score < min {
+				score = min
+			}
+			if score > max {
+				score = max
+			}
+
+			info := store.RatingInfo{
+				Score:  score,
+				Counts: noise.Count(r.Counts),
+			}
+			if r.Histogram != nil {
+				info.Histogram = make(map[string]int, len(r.Histogram))
+				for bucket, count := range r.Histogram {
+					info.Histogram[bucket] = noise.Count(count)
+				}
+			}
+
+			noised.Ratings[key] = info
+			scoreSum += score
+		}
+
+		if len(noised.Ratings) > 0 {
+			noised.AverageRating = scoreSum / float64(len(noised.Ratings))
+		}
+		summaries[id] = noised
+	}
+}
+
+// noiseSymptomReports perturbs the symptom counts and the number of check-ins of daily reports
+func (cds *CDS) noiseSymptomReports(reports ...*store.SymptomDailyReport) {
+	statistics := 0
+	for _, report := range reports {
+		statistics += len(report.Symptoms) + 1
+	}
+	noise := privacy.NewNoise(cds.privacy).Split(statistics)
+
+	for _, report := range reports {
+		for i := range report.Symptoms {
+			report.Symptoms[i].Count = noise.Count(report.Symptoms[i].Count)
+		}
+		report.CheckinsNumPastThreeDays = noise.Count(report.CheckinsNumPastThreeDays)
+	}
+}
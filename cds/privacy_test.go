@@ -0,0 +1,83 @@
+package cds
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitmark-inc/data-store/privacy"
+	"github.com/bitmark-inc/data-store/rating"
+	"github.com/bitmark-inc/data-store/store"
+)
+
+func TestNoisePOISummaries(t *testing.T) {
+	cds := New(nil, nil)
+	// scores can not be released without bounded rating keys
+	assert.Error(t, cds.SetDifferentialPrivacy(privacy.Config{Mechanism: privacy.Laplace, Epsilon: 1, Budget: 10}))
+	cds.SetRatingSchema(rating.Schema{Keys: []rating.KeySchema{{Key: "comment"}}})
+	assert.Error(t, cds.SetDifferentialPrivacy(privacy.Config{Mechanism: privacy.Laplace, Epsilon: 1, Budget: 10}))
+	assert.NoError(t, cds.SetDifferentialPrivacy(privacy.Config{}))
+
+	cds.SetRatingSchema(rating.Schema{Keys: []rating.KeySchema{{Key: "mask", Min: 1, Max: 5}}})
+	assert.Error(t, cds.SetDifferentialPrivacy(privacy.Config{Mechanism: privacy.Laplace}))
+	assert.NoError(t, cds.SetDifferentialPrivacy(privacy.Config{Mechanism: privacy.Laplace, Epsilon: 1, Budget: 10}))
+
+	stdDev := 0.5
+	summaries := map[string]store.POISummarizedRating{
+		"poi-1": {
+			ID:            "poi-1",
+			LastUpdated:   1000,
+			AverageRating: 4,
+			RatingCount:   20,
+			Ratings: map[string]store.RatingInfo{
+				"mask":  {Score: 4, Counts: 20, Weight: 20, StdDev: &stdDev, Histogram: map[string]int{"4": 20}},
+				"noise": {Score: 100, Counts: 20, Weight: 20},
+			},
+		},
+		"poi-2": {ID: "poi-2", Ratings: map[string]store.RatingInfo{}, Suppressed: true},
+	}
+	cds.noisePOISummaries(summaries)
+
+	noised := summaries["poi-1"]
+	assert.True(t, noised.Noised)
+	assert.Equal(t, int64(0), noised.LastUpdated)
+	assert.Len(t, noised.Ratings, 1)
+	mask := noised.Ratings["mask"]
+	assert.True(t, mask.Score >= 1 && mask.Score <= 5)
+	assert.Equal(t, mask.Score, noised.AverageRating)
+	assert.Nil(t, mask.StdDev)
+	assert.Len(t, mask.Histogram, 1)
+
+	assert.Equal(t, store.POISummarizedRating{ID: "poi-2", Ratings: map[string]store.RatingInfo{}, Suppressed: true}, summaries["poi-2"])
+}
+
+func TestNoiseSymptomReports(t *testing.T) {
+	cds := New(nil, nil)
+	cds.SetRatingSchema(rating.Schema{Keys: []rating.KeySchema{{Key: "mask", Min: 1, Max: 5}}})
+	assert.NoError(t, cds.SetDifferentialPrivacy(privacy.Config{Mechanism: privacy.Laplace, Epsilon: 1, Budget: 10}))
+
+	current := store.SymptomDailyReport{
+		Date:                     "2020-07-21",
+		Symptoms:                 []store.SymptomStats{{Name: "cough", Count: 8}},
+		CheckinsNumPastThreeDays: 100,
+	}
+	previous := store.SymptomDailyReport{
+		Date:     "2020-07-14",
+		Symptoms: []store.SymptomStats{{Name: "cough", Count: 3}},
+	}
+	cds.noiseSymptomReports(&current, &previous)
+
+	assert.True(t, current.CheckinsNumPastThreeDays >= 0)
+	assert.Equal(t, "2020-07-21", current.Date)
+	assert.Equal(t, "cough", current.Symptoms[0].Name)
+	assert.True(t, current.Symptoms[0].Count >= 0)
+	assert.True(t, previous.Symptoms[0].Count >= 0)
+}
+
+func TestPOISummaryVersion(t *testing.T) {
+	summary := store.POISummarizedRating{RatingCount: 3, LastUpdated: 1595000000000}
+	assert.Equal(t, "3:1595000000000", poiSummaryVersion(summary))
+
+	summary.RatingCount++
+	assert.NotEqual(t, "3:1595000000000", poiSummaryVersion(summary))
+}
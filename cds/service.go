@@ -2,17 +2,51 @@ package cds
 
 import (
 	"github.com/bitmark-inc/data-store/notification"
+	"github.com/bitmark-inc/data-store/privacy"
+	"github.com/bitmark-inc/data-store/rating"
 	"github.com/bitmark-inc/data-store/store"
 )
 
 type CDS struct {
-	dataStorePool      store.DataStorePool
-	notificationClient *notification.Client
+	dataStorePool store.DataStorePool
+	notifier      notification.Notifier
+
+	ratingPrior     store.BayesianPrior
+	ratingSchema    rating.Schema
+	minContributors int
+	privacy         privacy.Config
+
+	cohorts       map[string]Cohort
+	defaultCohort string
+	// symptoms are the ids of symptoms which can be checked in
+	symptoms map[string]struct{}
+
+	anomaly AnomalyConfig
+
+	notificationTemplates notification.Templates
+
+	poiNotification POINotificationConfig
 }
 
-func New(pool store.DataStorePool, client *notification.Client) *CDS {
+func New(pool store.DataStorePool, notifier notification.Notifier) *CDS {
 	return &CDS{
-		dataStorePool:      pool,
-		notificationClient: client,
+		dataStorePool: pool,
+		notifier:      notifier,
 	}
 }
+
+// SetRatingPrior sets the default prior of bayesian POI rating scores
+func (cds *CDS) SetRatingPrior(prior store.BayesianPrior) {
+	cds.ratingPrior = prior
+}
+
+// SetRatingSchema sets the schema which POI ratings are validated against
+func (cds *CDS) SetRatingSchema(schema rating.Schema) {
+	cds.ratingSchema = schema
+}
+
+// SetMinContributors sets the minimum number of contributors of a community aggregate.
+// Aggregates from less contributors are suppressed.
+func (cds *CDS) SetMinContributors(k int) {
+	cds.minContributors = k
+}
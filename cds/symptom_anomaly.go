@@ -0,0 +1,246 @@
+package cds
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/bitmark-inc/data-store/outbox"
+	"github.com/bitmark-inc/data-store/store"
+)
+
+const (
+	defaultAlertDays = 30
+	maxAlertDays     = 366
+)
+
+// AnomalyConfig configures the detection of spikes of symptoms in daily reports
+type AnomalyConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// BaselineDays is the number of days before a report which its baseline is computed from
+	BaselineDays int `mapstructure:"baseline_days"`
+	// MinBaselineDays is the minimum number of reported days in the baseline to detect spikes
+	MinBaselineDays int `mapstructure:"min_baseline_days"`
+	// Threshold is the z-score from which a daily count is flagged
+	Threshold float64 `mapstructure:"threshold"`
+}
+
+// Validate checks whether spikes can be detected with the configuration
+func (c AnomalyConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.MinBaselineDays < 2 {
+		return fmt.Errorf("min_baseline_days must be at least 2")
+	}
+	if c.BaselineDays < c.MinBaselineDays {
+		return fmt.Errorf("baseline_days must not be less than min_baseline_days")
+	}
+	if c.Threshold <= 0 {
+		return fmt.Errorf("threshold must be positive")
+	}
+	return nil
+}
+
+// OutboxKindSymptomAnomaly is the kind of outbox messages which detect symptom spikes from
+// uploaded report batches, and announce the batches
+const OutboxKindSymptomAnomaly = "symptom_anomaly"
+
+// SetAnomalyDetection makes each upload of daily reports trigger the detection of symptom spikes.
+// When it is enabled, users are notified of new alerts instead of the new report.
+func (cds *CDS) SetAnomalyDetection(config AnomalyConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	cds.anomaly = config
+	return nil
+}
+
+// enqueueAnomalyDetection puts the detection of spikes for a batch of a cohort into the outbox, so
+// that it survives restarts. Detections of a cohort are merged while pending, and only the latest
+// batch is announced.
+func (cds *CDS) enqueueAnomalyDetection(ctx context.Context, batch store.SymptomReportBatch) error {
+	msg, err := store.NewOutboxMessage(OutboxKindSymptomAnomaly, "",
+		fmt.Sprintf("%s:%s", OutboxKindSymptomAnomaly, batch.Cohort), batch)
+	if err != nil {
+		return err
+	}
+
+	return cds.dataStorePool.Outbox().Enqueue(ctx, msg)
+}
+
+// AnomalyDetectionWorker returns the worker which detects spikes of uploaded batches in the outbox
+func (cds *CDS) AnomalyDetectionWorker() *outbox.Worker {
+	return outbox.NewWorker(cds.dataStorePool.Outbox(), OutboxKindSymptomAnomaly, cds.detectBatchAnomalies)
+}
+
+// detectBatchAnomalies detects spikes from the reports of a cohort after a batch is uploaded, and
+// announces the batch with the alerts detected
+func (cds *CDS) detectBatchAnomalies(ctx context.Context, msg *store.OutboxMessage) error {
+	var batch store.SymptomReportBatch
+	if err := msg.DecodePayload(&batch); err != nil {
+		return outbox.Permanent(err)
+	}
+
+	logger := log.WithField("prefix", "symptom_anomaly").WithField("cohort", batch.Cohort)
+
+	// the new report is still announced if the detection fails
+	alerts, err := cds.DetectSymptomAnomalies(ctx, batch.Cohort, batch.Dates)
+	if err != nil {
+		logger.WithError(err).Error("fail to detect symptom anomalies")
+	} else if len(alerts) > 0 {
+		logger.WithField("alerts", len(alerts)).Info("symptom anomalies detected")
+	}
+
+	return cds.announceBatch(ctx, batch, alerts)
+}
+
+// DetectSymptomAnomalies flags spikes of symptoms in the daily reports of the dates of a cohort, or
+// in the latest report if no date is given, and returns the alerts which are not raised before
+func (cds *CDS) DetectSymptomAnomalies(ctx context.Context, cohort string, dates []string) ([]store.SymptomAlert, error) {
+	community := cds.dataStorePool.Community()
+	if len(dates) == 0 {
+		latest, err := community.FindLatestDailyReport(ctx, cohort)
+		if err != nil {
+			return nil, err
+		}
+		dates = []string{latest.Date}
+	}
+
+	sorted := make([]string, len(dates))
+	copy(sorted, dates)
+	sort.Strings(sorted)
+	first, err := time.Parse(store.SymptomCheckinDateLayout, sorted[0])
+	if err != nil {
+		return nil, err
+	}
+	start := first.AddDate(0, 0, -cds.anomaly.BaselineDays)
+
+	// symptoms missing from the report of a day are counted as zero in the series
+	series, err := community.GetSymptomTimeseries(ctx, cohort, start.Format(store.SymptomCheckinDateLayout), sorted[len(sorted)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	alerts := make([]store.SymptomAlert, 0)
+	for _, date := range sorted {
+		alerts = append(alerts, detectSymptomAnomalies(series, date, cds.anomaly, cds.minContributors)...)
+	}
+	for i := range alerts {
+		alerts[i].Cohort = cohort
+	}
+	return community.AddSymptomAlerts(ctx, alerts)
+}
+
+// detectSymptomAnomalies flags the symptoms whose counts on the date are above the mean of the
+// reported days of the baseline before the date by at least the threshold of standard deviations.
+// A standard deviation below one is taken as one, so that a flat baseline does not flag any small
+// increase. Counts from less than minContributors reports are never flagged.
+func detectSymptomAnomalies(series map[string][]store.Bucket, date string, config AnomalyConfig, minContributors int) []store.SymptomAlert {
+	alerts := make([]store.SymptomAlert, 0)
+	day, err := time.Parse(store.SymptomCheckinDateLayout, date)
+	if err != nil {
+		return alerts
+	}
+	baselineStart := day.AddDate(0, 0, -config.BaselineDays).Format(store.SymptomCheckinDateLayout)
+
+	for symptom, buckets := range series {
+		var value *int
+		baseline := make([]float64, 0, len(buckets))
+		for i, b := range buckets {
+			switch {
+			case b.Name == date:
+				value = &buckets[i].Value
+			case b.Name < date && b.Name >= baselineStart:
+				baseline = append(baseline, float64(b.Value))
+			}
+		}
+		if value == nil || *value < minContributors || len(baseline) < config.MinBaselineDays {
+			continue
+		}
+
+		mean, stdDev := meanAndStdDev(baseline)
+		z := (float64(*value) - mean) / math.Max(stdDev, 1)
+		if z < config.Threshold {
+			continue
+		}
+
+		alerts = append(alerts, store.SymptomAlert{
+			Symptom:      symptom,
+			Date:         date,
+			Value:        *value,
+			Mean:         mean,
+			StdDev:       stdDev,
+			ZScore:       z,
+			BaselineDays: len(baseline),
+		})
+	}
+
+	sort.Slice(alerts, func(i, j int) bool {
+		return alerts[i].ZScore > alerts[j].ZScore
+	})
+	return alerts
+}
+
+// meanAndStdDev returns the mean and the population standard deviation of values
+func meanAndStdDev(values []float64) (float64, float64) {
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+
+	variance := 0.0
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(variance / float64(len(values)))
+}
+
+// GetSymptomAlerts returns the alerts of a cohort of the past days. Only symptoms and dates of
+// alerts are released in the differential privacy mode, as the statistics of alerts are exact.
+func (cds *CDS) GetSymptomAlerts(c *gin.Context) {
+	var params struct {
+		Days int `form:"days"`
+	}
+	if err := c.BindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if params.Days < 0 || params.Days > maxAlertDays {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid days"})
+		return
+	}
+	if params.Days == 0 {
+		params.Days = defaultAlertDays
+	}
+	cohort, ok := cds.cohortParam(c)
+	if !ok {
+		return
+	}
+
+	since := time.Now().UTC().AddDate(0, 0, 1-params.Days).Format(store.SymptomCheckinDateLayout)
+	alerts, err := cds.dataStorePool.Community().GetSymptomAlerts(c, cohort.ID, since)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if cds.privacy.Enabled() {
+		redacted := make([]gin.H, 0, len(alerts))
+		for _, a := range alerts {
+			redacted = append(redacted, gin.H{"symptom": a.Symptom, "date": a.Date})
+		}
+		c.JSON(http.StatusOK, gin.H{"alerts": redacted})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"alerts": alerts})
+}
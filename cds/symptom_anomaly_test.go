@@ -0,0 +1,143 @@
+package cds
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitmark-inc/data-store/outbox"
+	"github.com/bitmark-inc/data-store/store"
+)
+
+func TestAnomalyConfigValidate(t *testing.T) {
+	assert.NoError(t, AnomalyConfig{}.Validate())
+	assert.NoError(t, AnomalyConfig{Enabled: true, BaselineDays: 28, MinBaselineDays: 7, Threshold: 3}.Validate())
+	assert.Error(t, AnomalyConfig{Enabled: true, BaselineDays: 28, MinBaselineDays: 1, Threshold: 3}.Validate())
+	assert.Error(t, AnomalyConfig{Enabled: true, BaselineDays: 5, MinBaselineDays: 7, Threshold: 3}.Validate())
+	assert.Error(t, AnomalyConfig{Enabled: true, BaselineDays: 28, MinBaselineDays: 7}.Validate())
+}
+
+func TestDetectSymptomAnomalies(t *testing.T) {
+	config := AnomalyConfig{Enabled: true, BaselineDays: 7, MinBaselineDays: 3, Threshold: 2}
+	series := map[string][]store.Bucket{
+		// a spike from a stable baseline
+		"Cough": {
+			{Name: "2020-07-18", Value: 10},
+			{Name: "2020-07-19", Value: 12},
+			{Name: "2020-07-20", Value: 8},
+			{Name: "2020-07-21", Value: 30},
+		},
+		// an increase within the variation of the baseline
+		"Fatigue": {
+			{Name: "2020-07-18", Value: 10},
+			{Name: "2020-07-19", Value: 30},
+			{Name: "2020-07-20", Value: 20},
+			{Name: "2020-07-21", Value: 35},
+		},
+		// a flat baseline does not flag a small increase
+		"Fever": {
+			{Name: "2020-07-18", Value: 5},
+			{Name: "2020-07-19", Value: 5},
+			{Name: "2020-07-20", Value: 5},
+			{Name: "2020-07-21", Value: 6},
+		},
+		// not enough baseline
+		"Headache": {
+			{Name: "2020-07-20", Value: 1},
+			{Name: "2020-07-21", Value: 50},
+		},
+		// not reported on the date
+		"Chills": {
+			{Name: "2020-07-18", Value: 5},
+			{Name: "2020-07-19", Value: 5},
+			{Name: "2020-07-20", Value: 5},
+		},
+	}
+
+	alerts := detectSymptomAnomalies(series, "2020-07-21", config, 0)
+	assert.Len(t, alerts, 1)
+	assert.Equal(t, "Cough", alerts[0].Symptom)
+	assert.Equal(t, "2020-07-21", alerts[0].Date)
+	assert.Equal(t, 30, alerts[0].Value)
+	assert.Equal(t, 10.0, alerts[0].Mean)
+	assert.Equal(t, 3, alerts[0].BaselineDays)
+	assert.InDelta(t, 20/alerts[0].StdDev, alerts[0].ZScore, 1e-9)
+
+	assert.Empty(t, detectSymptomAnomalies(series, "2020-07-21", config, 31))
+
+	// days before the baseline are excluded
+	config.BaselineDays = 2
+	assert.Empty(t, detectSymptomAnomalies(series, "2020-07-21", config, 0))
+}
+
+func TestDetectSymptomAnomaliesZeroFilledBaseline(t *testing.T) {
+	config := AnomalyConfig{Enabled: true, BaselineDays: 7, MinBaselineDays: 3, Threshold: 2}
+	series := store.SymptomTimeseries([]store.SymptomDailyReport{
+		{Date: "2020-07-17", Symptoms: []store.SymptomStats{{Name: "Cough", Count: 10}}},
+		{Date: "2020-07-18", Symptoms: []store.SymptomStats{{Name: "Cough", Count: 10}}},
+		{Date: "2020-07-19", Symptoms: []store.SymptomStats{{Name: "Cough", Count: 10}}},
+		{Date: "2020-07-20", Symptoms: []store.SymptomStats{{Name: "Cough", Count: 10}, {Name: "Rash", Count: 10}}},
+		{Date: "2020-07-21", Symptoms: []store.SymptomStats{{Name: "Cough", Count: 10}, {Name: "Rash", Count: 10}}},
+	})
+
+	// a symptom missing from the reports of the baseline counts as zero on those days
+	alerts := detectSymptomAnomalies(series, "2020-07-20", config, 0)
+	assert.Len(t, alerts, 1)
+	assert.Equal(t, "Rash", alerts[0].Symptom)
+	assert.Equal(t, 0.0, alerts[0].Mean)
+	assert.Equal(t, 3, alerts[0].BaselineDays)
+}
+
+func TestDetectBatchAnomaliesMalformed(t *testing.T) {
+	cds := New(nil, nil)
+	err := cds.detectBatchAnomalies(context.Background(), &store.OutboxMessage{Kind: OutboxKindSymptomAnomaly, Payload: []byte{0}})
+	assert.True(t, outbox.IsPermanent(err))
+}
+
+// fakeAnomalyStore serves the daily reports of anomaly detection from memory
+type fakeAnomalyStore struct {
+	store.DataStorePool
+	store.CommunityDataStore
+
+	reports []store.SymptomDailyReport
+}
+
+func (s *fakeAnomalyStore) Community() store.CommunityDataStore {
+	return s
+}
+
+func (s *fakeAnomalyStore) GetSymptomTimeseries(ctx context.Context, cohort, start, end string) (map[string][]store.Bucket, error) {
+	reports := make([]store.SymptomDailyReport, 0)
+	for _, r := range s.reports {
+		if r.Date >= start && r.Date <= end {
+			reports = append(reports, r)
+		}
+	}
+	return store.SymptomTimeseries(reports), nil
+}
+
+func (s *fakeAnomalyStore) AddSymptomAlerts(ctx context.Context, alerts []store.SymptomAlert) ([]store.SymptomAlert, error) {
+	return alerts, nil
+}
+
+func TestDetectSymptomAnomaliesOfDates(t *testing.T) {
+	fake := &fakeAnomalyStore{reports: []store.SymptomDailyReport{
+		{Date: "2020-07-17", Symptoms: []store.SymptomStats{{Name: "Cough", Count: 10}}},
+		{Date: "2020-07-18", Symptoms: []store.SymptomStats{{Name: "Cough", Count: 10}}},
+		{Date: "2020-07-19", Symptoms: []store.SymptomStats{{Name: "Cough", Count: 10}}},
+		{Date: "2020-07-20", Symptoms: []store.SymptomStats{{Name: "Cough", Count: 40}}},
+		{Date: "2020-07-21", Symptoms: []store.SymptomStats{{Name: "Cough", Count: 10}, {Name: "Fever", Count: 20}}},
+	}}
+	cds := New(fake, nil)
+	assert.NoError(t, cds.SetAnomalyDetection(AnomalyConfig{Enabled: true, BaselineDays: 7, MinBaselineDays: 3, Threshold: 2}))
+
+	// a spike on an earlier date of the batch is flagged as well as the latest one
+	alerts, err := cds.DetectSymptomAnomalies(context.Background(), "berkeley", []string{"2020-07-21", "2020-07-20"})
+	assert.NoError(t, err)
+	assert.Len(t, alerts, 2)
+	assert.Equal(t, store.SymptomAlert{Cohort: "berkeley", Symptom: "Cough", Date: "2020-07-20", Value: 40, Mean: 10, ZScore: 30, BaselineDays: 3}, alerts[0])
+	assert.Equal(t, "Fever", alerts[1].Symptom)
+	assert.Equal(t, "2020-07-21", alerts[1].Date)
+	assert.Equal(t, 0.0, alerts[1].Mean)
+}
@@ -0,0 +1,118 @@
+package cds
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/bitmark-inc/data-store/store"
+)
+
+// SetSymptoms sets the ids of symptoms which can be checked in. Check-ins of other symptoms are rejected.
+func (cds *CDS) SetSymptoms(symptoms []string) error {
+	known := make(map[string]struct{}, len(symptoms))
+	for _, s := range symptoms {
+		if s == "" || s != strings.TrimSpace(s) {
+			return fmt.Errorf("invalid symptom id %q", s)
+		}
+		if _, ok := known[s]; ok {
+			return fmt.Errorf("symptom %s is duplicated", s)
+		}
+		known[s] = struct{}{}
+	}
+
+	cds.symptoms = known
+	return nil
+}
+
+// unknownSymptoms returns the symptoms which are not configured
+func (cds *CDS) unknownSymptoms(symptoms []string) []string {
+	unknown := make([]string, 0)
+	for _, s := range symptoms {
+		if _, ok := cds.symptoms[s]; !ok {
+			unknown = append(unknown, s)
+		}
+	}
+	return unknown
+}
+
+// SetSymptomCheckin keeps a symptom check-in contributed by a personal data store. Symptoms are
+// normalized in the same way as the personal data store does, and unknown symptoms are rejected.
+func (cds *CDS) SetSymptomCheckin(c *gin.Context) {
+	accountNumber := c.GetString("account_number")
+
+	date, err := time.Parse(store.SymptomCheckinDateLayout, c.Param("date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date"})
+		return
+	}
+
+	var params struct {
+		Symptoms []string `json:"symptoms"`
+	}
+
+	if err := c.BindJSON(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	symptoms := store.NormalizeSymptoms(params.Symptoms)
+	if unknown := cds.unknownSymptoms(symptoms); len(unknown) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown symptoms", "symptoms": unknown})
+		return
+	}
+
+	if err := cds.dataStorePool.Community().SetSymptomCheckin(c, accountNumber, store.SymptomCheckin{
+		Date:     date.Format(store.SymptomCheckinDateLayout),
+		Symptoms: symptoms,
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"result": "ok"})
+}
+
+// AggregateSymptomCheckins replaces the daily reports of the default cohort of the past days,
+// including today, with the ones aggregated from check-ins. Reports of days uploaded in batches
+// are not replaced.
+func (cds *CDS) AggregateSymptomCheckins(ctx context.Context, now time.Time, days int) error {
+	end := now.UTC()
+	start := end.AddDate(0, 0, 1-days)
+
+	community := cds.dataStorePool.Community()
+	reports, err := community.AggregateSymptomCheckins(ctx,
+		start.Format(store.SymptomCheckinDateLayout), end.Format(store.SymptomCheckinDateLayout))
+	if err != nil {
+		return err
+	}
+	for i := range reports {
+		reports[i].Cohort = cds.defaultCohort
+	}
+
+	return community.AddSymptomDailyReports(ctx, reports)
+}
+
+// RunSymptomReportJob aggregates check-ins of the past days every interval until the context is done.
+// Reports of past days are aggregated again to include check-ins contributed late.
+func (cds *CDS) RunSymptomReportJob(ctx context.Context, interval time.Duration, days int) {
+	logger := log.WithField("prefix", "symptom_report")
+	logger.Info("symptom report job started")
+
+	for {
+		if err := cds.AggregateSymptomCheckins(ctx, time.Now(), days); err != nil {
+			logger.WithError(err).Error("fail to aggregate symptom check-ins")
+		}
+
+		select {
+		case <-ctx.Done():
+			logger.Info("symptom report job stopped")
+			return
+		case <-time.After(interval):
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package cds
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitmark-inc/data-store/store"
+)
+
+// fakeCheckinStore keeps the contributed check-ins in memory
+type fakeCheckinStore struct {
+	store.DataStorePool
+	store.CommunityDataStore
+
+	checkins []store.SymptomCheckin
+}
+
+func (s *fakeCheckinStore) Community() store.CommunityDataStore {
+	return s
+}
+
+func (s *fakeCheckinStore) SetSymptomCheckin(ctx context.Context, accountNumber string, checkin store.SymptomCheckin) error {
+	s.checkins = append(s.checkins, checkin)
+	return nil
+}
+
+func TestSetSymptoms(t *testing.T) {
+	cds := New(nil, nil)
+	assert.NoError(t, cds.SetSymptoms([]string{"cough", "fever"}))
+	assert.Equal(t, []string{"chills"}, cds.unknownSymptoms([]string{"chills", "cough"}))
+
+	assert.Error(t, cds.SetSymptoms([]string{"cough", "cough"}))
+	assert.Error(t, cds.SetSymptoms([]string{""}))
+	assert.Error(t, cds.SetSymptoms([]string{" cough"}))
+}
+
+func TestSetSymptomCheckin(t *testing.T) {
+	fake := &fakeCheckinStore{}
+	cds := New(fake, nil)
+	assert.NoError(t, cds.SetSymptoms([]string{"cough", "fever"}))
+
+	r := gin.New()
+	r.POST("/symptom-checkins/:date", cds.SetSymptomCheckin)
+	checkin := func(date, body string) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/symptom-checkins/"+date, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// symptoms are normalized like the ones checked in to the personal data store
+	assert.Equal(t, http.StatusOK, checkin("2020-07-21", `{"symptoms": [" fever", "cough", "", "fever "]}`))
+	assert.Equal(t, http.StatusOK, checkin("2020-07-22", `{}`))
+	assert.Equal(t, []store.SymptomCheckin{
+		{Date: "2020-07-21", Symptoms: []string{"cough", "fever"}},
+		{Date: "2020-07-22", Symptoms: []string{}},
+	}, fake.checkins)
+
+	assert.Equal(t, http.StatusBadRequest, checkin("2020-07-23", `{"symptoms": ["cough", "chills"]}`))
+	assert.Equal(t, http.StatusBadRequest, checkin("07-23", `{"symptoms": ["cough"]}`))
+	assert.Len(t, fake.checkins, 2)
+}
@@ -0,0 +1,225 @@
+package cds
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bitmark-inc/data-store/store"
+)
+
+const (
+	// checkinRowName is the name of the row which holds numbers of check-ins in the past three days
+	checkinRowName = "numberCheckInOnce_Last3days"
+	// notAvailable marks a cell without data
+	notAvailable = "NA"
+)
+
+// readSymptomCSV reads all records of a CSV file, which may be ragged
+func readSymptomCSV(r io.Reader) ([][]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	return reader.ReadAll()
+}
+
+// parseCount parses a non-negative count. It returns false if the cell is NA.
+func parseCount(cell string) (int, bool, error) {
+	cell = strings.TrimSpace(cell)
+	switch cell {
+	case notAvailable:
+		return 0, false, nil
+	case "":
+		return 0, false, fmt.Errorf("empty cell, use %s for missing data", notAvailable)
+	}
+
+	count, err := strconv.Atoi(cell)
+	if err != nil {
+		return 0, false, fmt.Errorf("%q is not an integer", cell)
+	}
+	if count < 0 {
+		return 0, false, fmt.Errorf("%d is negative", count)
+	}
+	return count, true, nil
+}
+
+// parseWideSymptomCSV converts a wide CSV file into daily reports. The header row holds
+// dates from the second column on, and each following row holds counts of a symptom by date,
+// except the check-in row which is identified by its name. A symptom with NA on a date is
+// left out of the report of the date, and NA check-ins are reported as zero. All errors
+// found are returned.
+func parseWideSymptomCSV(lines [][]string) ([]store.SymptomDailyReport, []uploadError) {
+	if len(lines) == 0 {
+		return nil, []uploadError{{Message: "empty file"}}
+	}
+
+	errs := make([]uploadError, 0)
+
+	header := lines[0]
+	if len(header) < 2 {
+		return nil, []uploadError{{Row: 1, Message: "no date column"}}
+	}
+
+	reports := make([]store.SymptomDailyReport, 0, len(header)-1)
+	dates := make(map[string]int)
+	for i, cell := range header[1:] {
+		column := i + 2
+		date := strings.TrimSpace(cell)
+		if _, err := time.Parse(store.SymptomCheckinDateLayout, date); err != nil {
+			errs = append(errs, uploadError{Row: 1, Column: column, Message: fmt.Sprintf("%q is not a date in YYYY-MM-DD", date)})
+		} else if c, ok := dates[date]; ok {
+			errs = append(errs, uploadError{Row: 1, Column: column, Message: fmt.Sprintf("date %s is duplicated with column %d", date, c)})
+		}
+		dates[date] = column
+		reports = append(reports, store.SymptomDailyReport{Date: date, Symptoms: []store.SymptomStats{}})
+	}
+
+	names := make(map[string]int)
+	for i, row := range lines[1:] {
+		rowNumber := i + 2
+		if len(row) != len(header) {
+			errs = append(errs, uploadError{Row: rowNumber, Message: fmt.Sprintf("expect %d columns but got %d", len(header), len(row))})
+			continue
+		}
+
+		name := strings.TrimSpace(row[0])
+		if name == "" {
+			errs = append(errs, uploadError{Row: rowNumber, Column: 1, Message: "empty name"})
+			continue
+		}
+		if r, ok := names[name]; ok {
+			errs = append(errs, uploadError{Row: rowNumber, Column: 1, Message: fmt.Sprintf("%s is duplicated with row %d", name, r)})
+			continue
+		}
+		names[name] = rowNumber
+
+		for j, cell := range row[1:] {
+			count, ok, err := parseCount(cell)
+			if err != nil {
+				errs = append(errs, uploadError{Row: rowNumber, Column: j + 2, Message: err.Error()})
+				continue
+			}
+			if !ok {
+				continue
+			}
+
+			if name == checkinRowName {
+				reports[j].CheckinsNumPastThreeDays = count
+			} else {
+				reports[j].Symptoms = append(reports[j].Symptoms, store.SymptomStats{Name: name, Count: count})
+			}
+		}
+	}
+
+	if _, ok := names[checkinRowName]; !ok {
+		errs = append(errs, uploadError{Message: fmt.Sprintf("no %s row", checkinRowName)})
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return reports, nil
+}
+
+// parseLongSymptomCSV converts a long CSV file, which has a row for each count of a symptom
+// on a date, into daily reports. The columns are identified by the header names date, symptom
+// and count. Check-ins are given as the symptom of the check-in row name. A count of NA is
+// left out, and dates are reported in ascending order.
+func parseLongSymptomCSV(lines [][]string) ([]store.SymptomDailyReport, []uploadError) {
+	if len(lines) == 0 {
+		return nil, []uploadError{{Message: "empty file"}}
+	}
+
+	errs := make([]uploadError, 0)
+
+	header := lines[0]
+	columns := map[string]int{"date": -1, "symptom": -1, "count": -1}
+	for i, cell := range header {
+		name := strings.ToLower(strings.TrimSpace(cell))
+		c, ok := columns[name]
+		if !ok {
+			errs = append(errs, uploadError{Row: 1, Column: i + 1, Message: fmt.Sprintf("unknown column %q", cell)})
+			continue
+		}
+		if c >= 0 {
+			errs = append(errs, uploadError{Row: 1, Column: i + 1, Message: fmt.Sprintf("column %s is duplicated with column %d", name, c+1)})
+			continue
+		}
+		columns[name] = i
+	}
+	for _, name := range []string{"date", "symptom", "count"} {
+		if columns[name] < 0 {
+			errs = append(errs, uploadError{Row: 1, Message: fmt.Sprintf("no %s column", name)})
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	reports := make(map[string]*store.SymptomDailyReport)
+	dates := make([]string, 0)
+	seen := make(map[string]int)
+	for i, row := range lines[1:] {
+		rowNumber := i + 2
+		if len(row) != len(header) {
+			errs = append(errs, uploadError{Row: rowNumber, Message: fmt.Sprintf("expect %d columns but got %d", len(header), len(row))})
+			continue
+		}
+
+		date := strings.TrimSpace(row[columns["date"]])
+		if _, err := time.Parse(store.SymptomCheckinDateLayout, date); err != nil {
+			errs = append(errs, uploadError{Row: rowNumber, Column: columns["date"] + 1, Message: fmt.Sprintf("%q is not a date in YYYY-MM-DD", date)})
+			continue
+		}
+
+		name := strings.TrimSpace(row[columns["symptom"]])
+		if name == "" {
+			errs = append(errs, uploadError{Row: rowNumber, Column: columns["symptom"] + 1, Message: "empty symptom"})
+			continue
+		}
+
+		key := date + "\x00" + name
+		if r, ok := seen[key]; ok {
+			errs = append(errs, uploadError{Row: rowNumber, Message: fmt.Sprintf("%s on %s is duplicated with row %d", name, date, r)})
+			continue
+		}
+		seen[key] = rowNumber
+
+		count, ok, err := parseCount(row[columns["count"]])
+		if err != nil {
+			errs = append(errs, uploadError{Row: rowNumber, Column: columns["count"] + 1, Message: err.Error()})
+			continue
+		}
+
+		report, found := reports[date]
+		if !found {
+			report = &store.SymptomDailyReport{Date: date, Symptoms: []store.SymptomStats{}}
+			reports[date] = report
+			dates = append(dates, date)
+		}
+		if !ok {
+			continue
+		}
+
+		if name == checkinRowName {
+			report.CheckinsNumPastThreeDays = count
+		} else {
+			report.Symptoms = append(report.Symptoms, store.SymptomStats{Name: name, Count: count})
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	sort.Strings(dates)
+	results := make([]store.SymptomDailyReport, 0, len(dates))
+	for _, date := range dates {
+		results = append(results, *reports[date])
+	}
+	return results, nil
+}
@@ -1,79 +1,137 @@
 package cds
 
 import (
-	"bufio"
-	"encoding/csv"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
 	"net/http"
 	"sort"
-	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
 
 	"github.com/bitmark-inc/data-store/store"
 )
 
-var (
-	notificationHeadingsNewReport = map[string]string{"en": "New Berkeley Data Available"}
-	notificationContentsNewReport = map[string]string{"en": "New user-reported data just dropped! Tap to view the latest health trends for the UC Berkeley Safe Campus Study."}
-)
-
+// AddSymptomDailyReports upserts daily reports uploaded as a file in the multipart form, or as
+// a JSON request body. The format is given by the format field, or implied by the content type.
+// Reports are uploaded for the cohort given by the cohort field, or the default cohort.
+// Each upload is recorded as a batch which can be rolled back.
 func (cds *CDS) AddSymptomDailyReports(c *gin.Context) {
-	file, err := c.FormFile("file")
+	var params struct {
+		DryRun bool   `form:"dry_run"`
+		Format string `form:"format"`
+		Cohort string `form:"cohort"`
+	}
+	if err := c.BindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var body []byte
+	var fileName string
+	contentType := c.ContentType()
+	if contentType == gin.MIMEJSON {
+		data, err := ioutil.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		body = data
+	} else {
+		file, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		f, err := file.Open()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		defer f.Close()
+
+		data, err := ioutil.ReadAll(f)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		body = data
+		fileName = file.Filename
+		contentType = file.Header.Get("Content-Type")
+		if params.Format == "" {
+			params.Format = c.PostForm("format")
+		}
+		if params.Cohort == "" {
+			params.Cohort = c.PostForm("cohort")
+		}
+	}
+
+	cohort, err := cds.lookupCohort(params.Cohort)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	f, err := file.Open()
+	format, err := uploadFormat(params.Format, contentType)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	defer f.Close()
 
-	reader := csv.NewReader(bufio.NewReader(f))
-	lines, err := reader.ReadAll()
+	reports, errs, err := parseSymptomUpload(format, bytes.NewReader(body))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	reports := transformToSymptomDailyReports(lines)
-	if err := cds.dataStorePool.Community().AddSymptomDailyReports(c, reports); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if len(errs) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + format, "errors": errs})
 		return
 	}
 
-	// TODO: log error
-	cds.notificationClient.NotifyActiveUsers(notificationHeadingsNewReport, notificationContentsNewReport)
+	for i := range reports {
+		reports[i].Cohort = cohort.ID
+	}
 
-	c.JSON(http.StatusOK, gin.H{"result": "ok"})
-}
+	if params.DryRun {
+		c.JSON(http.StatusOK, gin.H{"result": "ok", "dry_run": true, "reports": reports})
+		return
+	}
 
-func transformToSymptomDailyReports(lines [][]string) []store.SymptomDailyReport {
-	reports := make([]store.SymptomDailyReport, 0)
-	for _, col := range lines[0][1:] {
-		reports = append(reports, store.SymptomDailyReport{Date: col})
-	}
-	for i, row := range lines[1:] {
-		if i == len(lines[1:])-1 {
-			for i, cell := range row[1:] {
-				if cell != "NA" {
-					cnt, _ := strconv.Atoi(cell)
-					reports[i].CheckinsNumPastThreeDays = cnt
-				}
-			}
-			break
-		}
-		symptom := row[0]
-		for i, cell := range row[1:] {
-			cnt, _ := strconv.Atoi(cell)
-			reports[i].Symptoms = append(reports[i].Symptoms, store.SymptomStats{Name: symptom, Count: cnt})
+	hash := sha256.Sum256(body)
+	batch := store.SymptomReportBatch{
+		Cohort:   cohort.ID,
+		Uploader: c.GetString("account_number"),
+		Format:   format,
+		FileName: fileName,
+		FileHash: hex.EncodeToString(hash[:]),
+	}
+	if err := cds.dataStorePool.Community().AddSymptomReportBatch(c, &batch, reports); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// the detection worker notifies users of the new report, or of the alerts detected from it
+	announce := cds.enqueueAnomalyDetection
+	if !cds.anomaly.Enabled {
+		announce = func(ctx context.Context, batch store.SymptomReportBatch) error {
+			return cds.announceBatch(ctx, batch, nil)
 		}
 	}
-	return reports
+	if err := announce(c, batch); err != nil {
+		log.WithField("prefix", "notification").WithField("batch_id", batch.ID.Hex()).
+			WithError(err).Error("fail to announce report batch")
+	}
+
+	c.JSON(http.StatusOK, gin.H{"result": "ok", "batch": batch})
 }
 
+const maxReportItemDays = 366
+
 type reportItemQueryParams struct {
 	Days int64 `form:"days"`
 }
@@ -96,37 +154,118 @@ func (cds *CDS) GetSymptomReportItems(c *gin.Context) {
 	if params.Days != 0 {
 		days = params.Days
 	}
+	if days < 0 || days > maxReportItemDays {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid days"})
+		return
+	}
+	cohort, ok := cds.cohortParam(c)
+	if !ok {
+		return
+	}
 
-	latestReport, err := cds.dataStorePool.Community().FindLatestDailyReport(c)
+	latestReport, err := cds.dataStorePool.Community().FindLatestDailyReport(c, cohort.ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	currEnd, err := time.Parse("2006-01-02", latestReport.Date)
+	currEnd, err := time.Parse(store.SymptomCheckinDateLayout, latestReport.Date)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	prevEnd := currEnd.Add(-time.Hour * 24 * time.Duration(days))
+	// both windows span `days` calendar days, and the current window ends at the latest report
+	currStart := currEnd.AddDate(0, 0, 1-int(days))
+	prevEnd := currStart.AddDate(0, 0, -1)
+	prevStart := prevEnd.AddDate(0, 0, 1-int(days))
+
+	// the previous window is released with the current one, so that the budget is spent once for both
+	reports, err := cds.dataStorePool.Community().GetSymptomDailyReports(c, cohort.ID,
+		prevStart.Format(store.SymptomCheckinDateLayout), currEnd.Format(store.SymptomCheckinDateLayout))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	checkins := latestReport.CheckinsNumPastThreeDays
+	noised := cds.privacy.Enabled()
+	if noised {
+		// suppression of small buckets is applied afterwards, so that it depends on noised values only
+		if reports, err = cds.releaseSymptomReports(c, reports); err != nil {
+			writePrivacyError(c, err)
+			return
+		}
+		for _, report := range reports {
+			if report.Date == latestReport.Date {
+				checkins = report.CheckinsNumPastThreeDays
+			}
+		}
+	}
 
-	current, err := cds.dataStorePool.Community().GetSymptomReportItems(c, currEnd.Format("2006-01-02"), days)
+	current, currentCoverage, err := store.SymptomReportItems(reports,
+		currStart.Format(store.SymptomCheckinDateLayout), currEnd.Format(store.SymptomCheckinDateLayout))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	previous, err := cds.dataStorePool.Community().GetSymptomReportItems(c, prevEnd.Format("2006-01-02"), days)
+	previous, previousCoverage, err := store.SymptomReportItems(reports,
+		prevStart.Format(store.SymptomCheckinDateLayout), prevEnd.Format(store.SymptomCheckinDateLayout))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	current = suppressSmallBuckets(current, cds.minContributors)
+	previous = suppressSmallBuckets(previous, cds.minContributors)
+	publishedCheckins := suppressSmallCount(checkins, cds.minContributors)
+
 	results := gatherReportItemsWithDistribution(current, previous, false)
 	items := getReportItemsForDisplay(results, func(symptomID string) string {
 		return symptomID
 	})
-	c.JSON(http.StatusOK, gin.H{
+	response := gin.H{
+		"cohort":                       cohort.ID,
 		"report_items":                 items,
-		"checkins_num_past_three_days": latestReport.CheckinsNumPastThreeDays})
+		"checkins_num_past_three_days": publishedCheckins,
+		"coverage": gin.H{
+			"current":  currentCoverage,
+			"previous": previousCoverage,
+		},
+		"complete": currentCoverage.Complete && previousCoverage.Complete,
+	}
+	if noised {
+		response["noised"] = true
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// suppressSmallBuckets removes buckets counted from less than k reports, so that a
+// rarely reported symptom on a day can not be linked to individuals
+func suppressSmallBuckets(items map[string][]store.Bucket, k int) map[string][]store.Bucket {
+	if k <= 0 {
+		return items
+	}
+
+	results := make(map[string][]store.Bucket)
+	for itemID, buckets := range items {
+		kept := make([]store.Bucket, 0, len(buckets))
+		for _, b := range buckets {
+			if b.Value >= k {
+				kept = append(kept, b)
+			}
+		}
+		if len(kept) > 0 {
+			results[itemID] = kept
+		}
+	}
+	return results
+}
+
+// suppressSmallCount hides a count of less than k reports, which is published as null
+func suppressSmallCount(count, k int) *int {
+	if count < k {
+		return nil
+	}
+	return &count
 }
 
 func gatherReportItemsWithDistribution(currentBuckets, previousBuckets map[string][]store.Bucket, avg bool) map[string]*reportItem {
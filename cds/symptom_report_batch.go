@@ -0,0 +1,62 @@
+package cds
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	defaultBatchListLimit = 20
+	maxBatchListLimit     = 100
+)
+
+// ListSymptomReportBatches returns the latest uploads of symptom daily reports of a cohort,
+// or of all cohorts if no cohort is given
+func (cds *CDS) ListSymptomReportBatches(c *gin.Context) {
+	var params struct {
+		Limit  int64  `form:"limit"`
+		Cohort string `form:"cohort"`
+	}
+	if err := c.BindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if params.Limit < 0 || params.Limit > maxBatchListLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		return
+	}
+	if params.Limit == 0 {
+		params.Limit = defaultBatchListLimit
+	}
+	if params.Cohort != "" {
+		if _, err := cds.lookupCohort(params.Cohort); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	batches, err := cds.dataStorePool.Community().ListSymptomReportBatches(c, params.Cohort, params.Limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"batches": batches})
+}
+
+// RollbackSymptomReportBatch restores the reports replaced by an upload
+func (cds *CDS) RollbackSymptomReportBatch(c *gin.Context) {
+	batch, err := cds.dataStorePool.Community().RollbackSymptomReportBatch(c, c.Param("batch_id"), c.GetString("account_number"))
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"error": "batch not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"result": "ok", "batch": batch})
+}
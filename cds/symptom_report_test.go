@@ -7,14 +7,15 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-func TestTransformToSymptomDailyReports(t *testing.T) {
+func TestParseWideSymptomCSV(t *testing.T) {
 	lines := [][]string{
 		{"symptoms", "2020-07-19", "2020-07-20", "2020-07-21"},
 		{"Cough", "10", "7", "8"},
 		{"Fatigue", "18", "28", "29"},
 		{"numberCheckInOnce_Last3days", "NA", "NA", "1003"},
 	}
-	reports := transformToSymptomDailyReports(lines)
+	reports, errs := parseWideSymptomCSV(lines)
+	assert.Empty(t, errs)
 	assert.Equal(t, []store.SymptomDailyReport{
 		{
 			Date: "2020-07-19",
@@ -41,3 +42,60 @@ func TestTransformToSymptomDailyReports(t *testing.T) {
 			CheckinsNumPastThreeDays: 1003,
 		}}, reports)
 }
+
+func TestParseWideSymptomCSVNA(t *testing.T) {
+	lines := [][]string{
+		{"symptoms", "2020-07-20", "2020-07-21"},
+		{"numberCheckInOnce_Last3days", "900", "NA"},
+		{"Cough", "NA", "8"},
+	}
+	reports, errs := parseWideSymptomCSV(lines)
+	assert.Empty(t, errs)
+	assert.Equal(t, []store.SymptomDailyReport{
+		{Date: "2020-07-20", Symptoms: []store.SymptomStats{}, CheckinsNumPastThreeDays: 900},
+		{Date: "2020-07-21", Symptoms: []store.SymptomStats{{Name: "Cough", Count: 8}}},
+	}, reports)
+}
+
+func TestParseWideSymptomCSVErrors(t *testing.T) {
+	_, errs := parseWideSymptomCSV(nil)
+	assert.Equal(t, []uploadError{{Message: "empty file"}}, errs)
+
+	lines := [][]string{
+		{"symptoms", "2020-07-20", "07/21/2020", "2020-07-20"},
+		{"Cough", "abc", "-1", ""},
+		{"Fatigue", "1"},
+		{"", "1", "2", "3"},
+		{"Cough", "1", "2", "3"},
+	}
+	_, errs = parseWideSymptomCSV(lines)
+	assert.Equal(t, []uploadError{
+		{Row: 1, Column: 3, Message: `"07/21/2020" is not a date in YYYY-MM-DD`},
+		{Row: 1, Column: 4, Message: "date 2020-07-20 is duplicated with column 2"},
+		{Row: 2, Column: 2, Message: `"abc" is not an integer`},
+		{Row: 2, Column: 3, Message: "-1 is negative"},
+		{Row: 2, Column: 4, Message: "empty cell, use NA for missing data"},
+		{Row: 3, Message: "expect 4 columns but got 2"},
+		{Row: 4, Column: 1, Message: "empty name"},
+		{Row: 5, Column: 1, Message: "Cough is duplicated with row 2"},
+		{Message: "no numberCheckInOnce_Last3days row"},
+	}, errs)
+}
+
+func TestSuppressSmallBuckets(t *testing.T) {
+	items := map[string][]store.Bucket{
+		"cough":   {{Name: "2020-07-20", Value: 2}, {Name: "2020-07-21", Value: 8}},
+		"fatigue": {{Name: "2020-07-20", Value: 1}},
+	}
+
+	assert.Equal(t, items, suppressSmallBuckets(items, 0))
+	assert.Equal(t, map[string][]store.Bucket{
+		"cough": {{Name: "2020-07-21", Value: 8}},
+	}, suppressSmallBuckets(items, 5))
+}
+
+func TestSuppressSmallCount(t *testing.T) {
+	assert.Equal(t, 3, *suppressSmallCount(3, 0))
+	assert.Equal(t, 5, *suppressSmallCount(5, 5))
+	assert.Nil(t, suppressSmallCount(4, 5))
+}
@@ -0,0 +1,215 @@
+package cds
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bitmark-inc/data-store/store"
+)
+
+const (
+	GranularityDay   = "day"
+	GranularityWeek  = "week"
+	GranularityMonth = "month"
+
+	movingAverageDays = 7
+	maxTimeseriesDays = 731
+)
+
+type timeseriesQueryParams struct {
+	Start         string `form:"start" binding:"required"`
+	End           string `form:"end" binding:"required"`
+	Granularity   string `form:"granularity"`
+	MovingAverage bool   `form:"moving_average"`
+}
+
+// dateRange validates the query and returns the first and last days of the series
+func (p *timeseriesQueryParams) dateRange() (time.Time, time.Time, error) {
+	start, err := time.Parse("2006-01-02", p.Start)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid start date")
+	}
+	end, err := time.Parse("2006-01-02", p.End)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid end date")
+	}
+	if end.Before(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("end date must not be before start date")
+	}
+	if end.Sub(start) >= maxTimeseriesDays*24*time.Hour {
+		return time.Time{}, time.Time{}, fmt.Errorf("date range must not exceed %d days", maxTimeseriesDays)
+	}
+
+	switch p.Granularity {
+	case "":
+		p.Granularity = GranularityDay
+	case GranularityDay, GranularityWeek, GranularityMonth:
+	default:
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid granularity")
+	}
+	if p.MovingAverage && p.Granularity != GranularityDay {
+		return time.Time{}, time.Time{}, fmt.Errorf("moving average is only available for daily series")
+	}
+
+	return start, end, nil
+}
+
+type timeseriesPoint struct {
+	Date          string   `json:"date"`
+	Value         int      `json:"value"`
+	MovingAverage *float64 `json:"moving_average,omitempty"`
+}
+
+type timeseries struct {
+	ID     string            `json:"id"`
+	Name   string            `json:"name"`
+	Points []timeseriesPoint `json:"points"`
+}
+
+// GetSymptomTimeseries returns the series of each symptom of a cohort between the start and
+// end dates. Daily values can be rolled up by week, starting on Monday, or by month.
+func (cds *CDS) GetSymptomTimeseries(c *gin.Context) {
+	var params timeseriesQueryParams
+	if err := c.BindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	start, end, err := params.dateRange()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	cohort, ok := cds.cohortParam(c)
+	if !ok {
+		return
+	}
+
+	// the moving average of the first days requires reports before the start date
+	queryStart := start
+	if params.MovingAverage {
+		queryStart = start.AddDate(0, 0, -(movingAverageDays - 1))
+	}
+
+	reports, err := cds.dataStorePool.Community().GetSymptomDailyReports(c, cohort.ID, queryStart.Format("2006-01-02"), end.Format("2006-01-02"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	noised := cds.privacy.Enabled()
+	if noised {
+		if reports, err = cds.releaseSymptomReports(c, reports); err != nil {
+			writePrivacyError(c, err)
+			return
+		}
+	}
+	reported := make([]string, 0, len(reports))
+	for _, report := range reports {
+		reported = append(reported, report.Date)
+	}
+	sort.Strings(reported)
+	daily := store.SymptomTimeseries(reports)
+	daily = suppressSmallBuckets(daily, cds.minContributors)
+
+	series := make([]timeseries, 0, len(daily))
+	for symptomID, buckets := range daily {
+		var points []timeseriesPoint
+		switch params.Granularity {
+		case GranularityDay:
+			points = dailyPoints(buckets, reported, params.Start, params.MovingAverage)
+		case GranularityWeek:
+			points = rollUpBuckets(buckets, weekOf)
+		case GranularityMonth:
+			points = rollUpBuckets(buckets, monthOf)
+		}
+		if len(points) == 0 {
+			continue
+		}
+		series = append(series, timeseries{ID: symptomID, Name: symptomID, Points: points})
+	}
+	sort.Slice(series, func(i, j int) bool {
+		return series[i].ID < series[j].ID
+	})
+
+	response := gin.H{
+		"cohort":      cohort.ID,
+		"start":       params.Start,
+		"end":         params.End,
+		"granularity": params.Granularity,
+		"series":      series,
+	}
+	if noised {
+		response["noised"] = true
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// dailyPoints returns the points from the start date. The moving average of a day is the mean of
+// the values in the trailing window of seven days over the reported dates of the window, where a
+// reported date without a value, e.g. a suppressed one, counts as zero.
+func dailyPoints(buckets []store.Bucket, reported []string, start string, movingAverage bool) []timeseriesPoint {
+	points := make([]timeseriesPoint, 0, len(buckets))
+	for i, b := range buckets {
+		if b.Name < start {
+			continue
+		}
+
+		point := timeseriesPoint{Date: b.Name, Value: b.Value}
+		if movingAverage {
+			day, _ := time.Parse("2006-01-02", b.Name)
+			windowStart := day.AddDate(0, 0, -(movingAverageDays - 1)).Format("2006-01-02")
+
+			sum := 0
+			for j := i; j >= 0 && buckets[j].Name >= windowStart; j-- {
+				sum += buckets[j].Value
+			}
+			n := 0
+			for _, d := range reported {
+				if d >= windowStart && d <= b.Name {
+					n++
+				}
+			}
+			average := 0.0
+			if n > 0 {
+				average = float64(sum) / float64(n)
+			}
+			point.MovingAverage = &average
+		}
+		points = append(points, point)
+	}
+	return points
+}
+
+// rollUpBuckets sums the values of days in the same period, which is identified by the period function
+func rollUpBuckets(buckets []store.Bucket, period func(time.Time) string) []timeseriesPoint {
+	points := make([]timeseriesPoint, 0)
+	for _, b := range buckets {
+		day, err := time.Parse("2006-01-02", b.Name)
+		if err != nil {
+			continue
+		}
+
+		p := period(day)
+		if n := len(points); n > 0 && points[n-1].Date == p {
+			points[n-1].Value += b.Value
+			continue
+		}
+		points = append(points, timeseriesPoint{Date: p, Value: b.Value})
+	}
+	return points
+}
+
+// weekOf returns the Monday of the week of a day
+func weekOf(day time.Time) string {
+	offset := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -offset).Format("2006-01-02")
+}
+
+// monthOf returns the month of a day
+func monthOf(day time.Time) string {
+	return day.Format("2006-01")
+}
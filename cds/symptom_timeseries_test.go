@@ -0,0 +1,77 @@
+package cds
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitmark-inc/data-store/store"
+)
+
+func TestTimeseriesQueryParamsDateRange(t *testing.T) {
+	params := timeseriesQueryParams{Start: "2020-07-01", End: "2020-07-31"}
+	_, _, err := params.dateRange()
+	assert.NoError(t, err)
+	assert.Equal(t, GranularityDay, params.Granularity)
+
+	for _, p := range []timeseriesQueryParams{
+		{Start: "07/01/2020", End: "2020-07-31"},
+		{Start: "2020-07-01", End: "2020-06-30"},
+		{Start: "2018-07-01", End: "2020-07-31"},
+		{Start: "2020-07-01", End: "2020-07-31", Granularity: "year"},
+		{Start: "2020-07-01", End: "2020-07-31", Granularity: GranularityWeek, MovingAverage: true},
+	} {
+		_, _, err := p.dateRange()
+		assert.Error(t, err, p)
+	}
+}
+
+func TestDailyPoints(t *testing.T) {
+	buckets := []store.Bucket{
+		{Name: "2020-07-01", Value: 7},
+		{Name: "2020-07-05", Value: 3},
+		{Name: "2020-07-07", Value: 2},
+		{Name: "2020-07-08", Value: 4},
+	}
+
+	reported := []string{"2020-07-01", "2020-07-05", "2020-07-07", "2020-07-08"}
+
+	points := dailyPoints(buckets, reported, "2020-07-05", false)
+	assert.Equal(t, []timeseriesPoint{
+		{Date: "2020-07-05", Value: 3},
+		{Date: "2020-07-07", Value: 2},
+		{Date: "2020-07-08", Value: 4},
+	}, points)
+
+	points = dailyPoints(buckets, reported, "2020-07-05", true)
+	assert.Len(t, points, 3)
+	assert.Equal(t, 5.0, *points[0].MovingAverage)
+	assert.Equal(t, 4.0, *points[1].MovingAverage)
+	assert.Equal(t, 3.0, *points[2].MovingAverage)
+
+	// reported dates without a value count as zero
+	reported = []string{"2020-07-01", "2020-07-04", "2020-07-05", "2020-07-06", "2020-07-07", "2020-07-08"}
+	points = dailyPoints(buckets, reported, "2020-07-05", true)
+	assert.Equal(t, 10.0/3, *points[0].MovingAverage)
+	assert.Equal(t, 12.0/5, *points[1].MovingAverage)
+	assert.Equal(t, 9.0/5, *points[2].MovingAverage)
+}
+
+func TestRollUpBuckets(t *testing.T) {
+	buckets := []store.Bucket{
+		{Name: "2020-07-26", Value: 1},
+		{Name: "2020-07-27", Value: 2},
+		{Name: "2020-08-02", Value: 3},
+		{Name: "2020-08-03", Value: 4},
+	}
+
+	assert.Equal(t, []timeseriesPoint{
+		{Date: "2020-07-20", Value: 1},
+		{Date: "2020-07-27", Value: 5},
+		{Date: "2020-08-03", Value: 4},
+	}, rollUpBuckets(buckets, weekOf))
+	assert.Equal(t, []timeseriesPoint{
+		{Date: "2020-07", Value: 3},
+		{Date: "2020-08", Value: 7},
+	}, rollUpBuckets(buckets, monthOf))
+}
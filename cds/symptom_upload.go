@@ -0,0 +1,153 @@
+package cds
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+	"time"
+
+	"github.com/bitmark-inc/data-store/store"
+)
+
+// formats of uploaded symptom daily reports
+const (
+	formatWideCSV = "wide_csv"
+	formatLongCSV = "long_csv"
+	formatJSON    = "json"
+)
+
+// uploadError is an error found in uploaded symptom daily reports. For CSV files, rows and
+// columns start from 1, as shown in spreadsheets. Zero column means the error is about the
+// whole row, and zero row means the error is about the whole file. For JSON, the path
+// locates the invalid value.
+type uploadError struct {
+	Row     int    `json:"row,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message"`
+}
+
+func (e uploadError) Error() string {
+	if e.Path != "" {
+		return fmt.Sprintf("%s: %s", e.Path, e.Message)
+	}
+	return fmt.Sprintf("row %d column %d: %s", e.Row, e.Column, e.Message)
+}
+
+// uploadFormat returns the format given explicitly, or the one implied by the content type.
+// CSV files are wide by default.
+func uploadFormat(format, contentType string) (string, error) {
+	switch format {
+	case formatWideCSV, formatLongCSV, formatJSON:
+		return format, nil
+	case "":
+	default:
+		return "", fmt.Errorf("unknown format %s", format)
+	}
+
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil && mediaType == "application/json" {
+		return formatJSON, nil
+	}
+	return formatWideCSV, nil
+}
+
+// parseSymptomUpload converts uploaded symptom daily reports of a format. An error is returned
+// if the content can not be read at all, otherwise all invalid values found are returned.
+func parseSymptomUpload(format string, r io.Reader) ([]store.SymptomDailyReport, []uploadError, error) {
+	if format == formatJSON {
+		reports, errs := parseSymptomJSON(r)
+		return reports, errs, nil
+	}
+
+	lines, err := readSymptomCSV(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if format == formatLongCSV {
+		reports, errs := parseLongSymptomCSV(lines)
+		return reports, errs, nil
+	}
+
+	reports, errs := parseWideSymptomCSV(lines)
+	return reports, errs, nil
+}
+
+// parseSymptomJSON converts a JSON array of daily reports. A null count is treated as NA
+// and left out.
+func parseSymptomJSON(r io.Reader) ([]store.SymptomDailyReport, []uploadError) {
+	var items []struct {
+		Date     string `json:"date"`
+		Symptoms []struct {
+			Name  string `json:"name"`
+			Count *int   `json:"count"`
+		} `json:"symptoms"`
+		CheckinsNumPastThreeDays *int `json:"checkins_num_past_three_days"`
+	}
+
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&items); err != nil {
+		return nil, []uploadError{{Message: err.Error()}}
+	}
+	if len(items) == 0 {
+		return nil, []uploadError{{Message: "no report"}}
+	}
+
+	errs := make([]uploadError, 0)
+	reports := make([]store.SymptomDailyReport, 0, len(items))
+	dates := make(map[string]int)
+	for i, item := range items {
+		path := fmt.Sprintf("[%d]", i)
+
+		date := strings.TrimSpace(item.Date)
+		if _, err := time.Parse(store.SymptomCheckinDateLayout, date); err != nil {
+			errs = append(errs, uploadError{Path: path + ".date", Message: fmt.Sprintf("%q is not a date in YYYY-MM-DD", date)})
+		} else if d, ok := dates[date]; ok {
+			errs = append(errs, uploadError{Path: path + ".date", Message: fmt.Sprintf("date %s is duplicated with [%d]", date, d)})
+		}
+		dates[date] = i
+
+		report := store.SymptomDailyReport{Date: date, Symptoms: []store.SymptomStats{}}
+		if item.CheckinsNumPastThreeDays != nil {
+			if *item.CheckinsNumPastThreeDays < 0 {
+				errs = append(errs, uploadError{Path: path + ".checkins_num_past_three_days", Message: fmt.Sprintf("%d is negative", *item.CheckinsNumPastThreeDays)})
+			}
+			report.CheckinsNumPastThreeDays = *item.CheckinsNumPastThreeDays
+		}
+
+		names := make(map[string]int)
+		for j, symptom := range item.Symptoms {
+			symptomPath := fmt.Sprintf("%s.symptoms[%d]", path, j)
+
+			name := strings.TrimSpace(symptom.Name)
+			if name == "" {
+				errs = append(errs, uploadError{Path: symptomPath + ".name", Message: "empty name"})
+				continue
+			}
+			if n, ok := names[name]; ok {
+				errs = append(errs, uploadError{Path: symptomPath + ".name", Message: fmt.Sprintf("%s is duplicated with symptoms[%d]", name, n)})
+				continue
+			}
+			names[name] = j
+
+			if symptom.Count == nil {
+				continue
+			}
+			if *symptom.Count < 0 {
+				errs = append(errs, uploadError{Path: symptomPath + ".count", Message: fmt.Sprintf("%d is negative", *symptom.Count)})
+				continue
+			}
+			report.Symptoms = append(report.Symptoms, store.SymptomStats{Name: name, Count: *symptom.Count})
+		}
+
+		reports = append(reports, report)
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return reports, nil
+}
@@ -0,0 +1,185 @@
+package cds
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitmark-inc/data-store/store"
+)
+
+var (
+	testWideSymptomCSV = `symptoms,2020-07-20,2020-07-21
+Cough,10,NA
+Fatigue,18,28
+numberCheckInOnce_Last3days,NA,1003
+`
+	testLongSymptomCSV = `date,symptom,count
+2020-07-21,Fatigue,28
+2020-07-20,Cough,10
+2020-07-20,Fatigue,18
+2020-07-21,Cough,NA
+2020-07-21,numberCheckInOnce_Last3days,1003
+`
+	testSymptomJSON = `[
+	{"date": "2020-07-20", "symptoms": [{"name": "Cough", "count": 10}, {"name": "Fatigue", "count": 18}]},
+	{"date": "2020-07-21", "symptoms": [{"name": "Cough", "count": null}, {"name": "Fatigue", "count": 28}], "checkins_num_past_three_days": 1003}
+]`
+
+	testSymptomReports = []store.SymptomDailyReport{
+		{
+			Date:     "2020-07-20",
+			Symptoms: []store.SymptomStats{{Name: "Cough", Count: 10}, {Name: "Fatigue", Count: 18}},
+		},
+		{
+			Date:                     "2020-07-21",
+			Symptoms:                 []store.SymptomStats{{Name: "Fatigue", Count: 28}},
+			CheckinsNumPastThreeDays: 1003,
+		},
+	}
+)
+
+func TestUploadFormat(t *testing.T) {
+	for _, c := range []struct {
+		format      string
+		contentType string
+		expected    string
+	}{
+		{"", "", formatWideCSV},
+		{"", "text/csv", formatWideCSV},
+		{"", "application/json; charset=utf-8", formatJSON},
+		{formatLongCSV, "text/csv", formatLongCSV},
+		{formatWideCSV, "application/json", formatWideCSV},
+	} {
+		format, err := uploadFormat(c.format, c.contentType)
+		assert.NoError(t, err)
+		assert.Equal(t, c.expected, format)
+	}
+
+	_, err := uploadFormat("xlsx", "")
+	assert.Error(t, err)
+}
+
+func TestParseSymptomUpload(t *testing.T) {
+	for format, content := range map[string]string{
+		formatWideCSV: testWideSymptomCSV,
+		formatLongCSV: testLongSymptomCSV,
+		formatJSON:    testSymptomJSON,
+	} {
+		reports, errs, err := parseSymptomUpload(format, strings.NewReader(content))
+		assert.NoError(t, err, format)
+		assert.Empty(t, errs, format)
+		assert.Equal(t, testSymptomReports, reports, format)
+	}
+}
+
+func TestParseLongSymptomCSVErrors(t *testing.T) {
+	_, errs := parseLongSymptomCSV([][]string{{"date", "name", "count"}})
+	assert.Equal(t, []uploadError{
+		{Row: 1, Column: 2, Message: `unknown column "name"`},
+		{Row: 1, Message: "no symptom column"},
+	}, errs)
+
+	_, errs = parseLongSymptomCSV([][]string{
+		{"symptom", "date", "count"},
+		{"Cough", "2020/07/20", "1"},
+		{"", "2020-07-20", "1"},
+		{"Cough", "2020-07-20", "x"},
+		{"Cough", "2020-07-20", "2"},
+		{"Cough", "2020-07-21"},
+	})
+	assert.Equal(t, []uploadError{
+		{Row: 2, Column: 2, Message: `"2020/07/20" is not a date in YYYY-MM-DD`},
+		{Row: 3, Column: 1, Message: "empty symptom"},
+		{Row: 4, Column: 3, Message: `"x" is not an integer`},
+		{Row: 5, Message: "Cough on 2020-07-20 is duplicated with row 4"},
+		{Row: 6, Message: "expect 3 columns but got 2"},
+	}, errs)
+}
+
+func TestParseSymptomJSONErrors(t *testing.T) {
+	_, errs := parseSymptomJSON(strings.NewReader(`{"date": "2020-07-20"}`))
+	assert.Len(t, errs, 1)
+
+	_, errs = parseSymptomJSON(strings.NewReader(`[{"date": "2020-07-20", "unknown": 1}]`))
+	assert.Len(t, errs, 1)
+
+	_, errs = parseSymptomJSON(strings.NewReader(`[
+		{"date": "2020-07-20", "symptoms": [{"name": "", "count": 1}, {"name": "Cough", "count": -1}]},
+		{"date": "2020-07-20", "checkins_num_past_three_days": -5}
+	]`))
+	assert.Equal(t, []uploadError{
+		{Path: "[0].symptoms[0].name", Message: "empty name"},
+		{Path: "[0].symptoms[1].count", Message: "-1 is negative"},
+		{Path: "[1].date", Message: "date 2020-07-20 is duplicated with [0]"},
+		{Path: "[1].checkins_num_past_three_days", Message: "-5 is negative"},
+	}, errs)
+}
+
+func TestAddSymptomDailyReportsDryRun(t *testing.T) {
+	cds := New(nil, nil)
+	assert.NoError(t, cds.SetCohorts(testCohorts, "berkeley"))
+	r := gin.New()
+	r.POST("/symptom-daily-reports", cds.AddSymptomDailyReports)
+
+	var form bytes.Buffer
+	writer := multipart.NewWriter(&form)
+	assert.NoError(t, writer.WriteField("format", formatLongCSV))
+	assert.NoError(t, writer.WriteField("cohort", "davis"))
+	part, err := writer.CreateFormFile("file", "reports.csv")
+	assert.NoError(t, err)
+	_, err = part.Write([]byte(testLongSymptomCSV))
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+
+	for _, tc := range []struct {
+		contentType string
+		body        io.Reader
+		cohort      string
+	}{
+		{writer.FormDataContentType(), &form, "davis"},
+		{"application/json", strings.NewReader(testSymptomJSON), "berkeley"},
+	} {
+		req := httptest.NewRequest("POST", "/symptom-daily-reports?dry_run=true", tc.body)
+		req.Header.Set("Content-Type", tc.contentType)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var resp struct {
+			DryRun  bool                       `json:"dry_run"`
+			Reports []store.SymptomDailyReport `json:"reports"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.True(t, resp.DryRun)
+		assert.Len(t, resp.Reports, len(testSymptomReports))
+		for i, report := range resp.Reports {
+			assert.Equal(t, tc.cohort, report.Cohort)
+			report.Cohort = ""
+			assert.Equal(t, testSymptomReports[i], report)
+		}
+	}
+
+	req := httptest.NewRequest("POST", "/symptom-daily-reports?dry_run=true", strings.NewReader(`[{"date": "today"}]`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"path":"[0].date"`)
+
+	req = httptest.NewRequest("POST", "/symptom-daily-reports?dry_run=true&cohort=unknown", strings.NewReader(testSymptomJSON))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "unknown cohort")
+}
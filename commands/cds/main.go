@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"encoding/hex"
+	"expvar"
 	"flag"
 	"fmt"
 	"net/http"
@@ -12,6 +13,7 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 	prefixed "github.com/x-cray/logrus-prefixed-formatter"
@@ -22,12 +24,18 @@ import (
 	"github.com/bitmark-inc/bitmark-sdk-go/account"
 	"github.com/bitmark-inc/data-store/cds"
 	"github.com/bitmark-inc/data-store/notification"
+	"github.com/bitmark-inc/data-store/privacy"
+	"github.com/bitmark-inc/data-store/rating"
 	"github.com/bitmark-inc/data-store/store"
 	"github.com/bitmark-inc/data-store/web"
 )
 
+// defaultNotificationTemplateDir is the folder of the notification templates shipped with the service
+const defaultNotificationTemplateDir = "./notification_templates"
+
 var (
-	server *web.Server
+	server     *web.Server
+	cancelJobs context.CancelFunc
 )
 
 func initLog() {
@@ -85,6 +93,11 @@ func main() {
 			<-initialCtx.Done()
 		}
 
+		if cancelJobs != nil {
+			log.Info("Stop background jobs")
+			cancelJobs()
+		}
+
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 
@@ -137,18 +150,138 @@ func main() {
 		log.Panic(err)
 	}
 
-	client := notification.NewClient(viper.GetString("onesignal.app_id"), viper.GetString("onesignal.app_key"))
-	cds := cds.New(store.NewMongodbDataPool(mongoClient, viper.GetString("server.store_prefix")), client)
+	pseudonymKey, err := hex.DecodeString(viper.GetString("server.pseudonym_key"))
+	if err != nil {
+		log.Panic(err)
+	}
+	if len(pseudonymKey) == 0 {
+		log.Panic("pseudonym key is required")
+	}
+
+	dataStorePool := store.NewMongodbDataPool(mongoClient, viper.GetString("server.store_prefix"))
+	dataStorePool.SetPseudonymKey(pseudonymKey)
+	if err := dataStorePool.InitOutboxStore(); err != nil {
+		log.Panicf("initiate outbox store with error: %s", err)
+	}
+
+	var notifier notification.Notifier
+	switch provider := viper.GetString("notification.provider"); provider {
+	case "", "onesignal":
+		notifier = notification.NewClient(viper.GetString("onesignal.app_id"), viper.GetString("onesignal.app_key"))
+	case "log":
+		notifier = notification.Logger{}
+	default:
+		log.Panicf("unknown notification provider %s", provider)
+	}
+
+	var cohorts []cds.Cohort
+	if err := viper.UnmarshalKey("symptom_report.cohorts", &cohorts); err != nil {
+		log.Panic(err)
+	}
+
+	var anomalyConfig cds.AnomalyConfig
+	if err := viper.UnmarshalKey("symptom_report.anomaly", &anomalyConfig); err != nil {
+		log.Panic(err)
+	}
+	var poiNotificationConfig cds.POINotificationConfig
+	if err := viper.UnmarshalKey("poi_rating.notification", &poiNotificationConfig); err != nil {
+		log.Panic(err)
+	}
+
+	cds := cds.New(dataStorePool, notifier)
+	cds.SetRatingPrior(store.BayesianPrior{
+		Mean:   viper.GetFloat64("poi_rating.prior_mean"),
+		Weight: viper.GetFloat64("poi_rating.prior_weight"),
+	})
+
+	var ratingSchema rating.Schema
+	if err := viper.UnmarshalKey("poi_rating.schema", &ratingSchema); err != nil {
+		log.Panic(err)
+	}
+	cds.SetRatingSchema(ratingSchema)
+	cds.SetMinContributors(viper.GetInt("privacy.min_contributors"))
+	if err := cds.SetCohorts(cohorts, viper.GetString("symptom_report.default_cohort")); err != nil {
+		log.Panic(err)
+	}
+	if err := cds.SetSymptoms(viper.GetStringSlice("symptom_report.symptoms")); err != nil {
+		log.Panic(err)
+	}
+
+	// the templates shipped with the service are loaded unless another folder is configured
+	templateDir := viper.GetString("notification.template_dir")
+	if templateDir == "" {
+		templateDir = defaultNotificationTemplateDir
+	}
+	notificationTemplates, err := notification.LoadTemplates(templateDir)
+	if err != nil {
+		log.Panic(err)
+	}
+	if err := cds.SetNotificationTemplates(notificationTemplates); err != nil {
+		log.Panic(err)
+	}
+
+	var privacyConfig privacy.Config
+	if err := viper.UnmarshalKey("privacy.noise", &privacyConfig); err != nil {
+		log.Panic(err)
+	}
+	if err := cds.SetDifferentialPrivacy(privacyConfig); err != nil {
+		log.Panic(err)
+	}
+
+	var jobCtx context.Context
+	jobCtx, cancelJobs = context.WithCancel(context.Background())
+	if viper.GetBool("symptom_report.aggregation.enabled") {
+		interval := viper.GetDuration("symptom_report.aggregation.interval")
+		days := viper.GetInt("symptom_report.aggregation.days")
+		if interval <= 0 || days <= 0 {
+			log.Panic("invalid symptom report aggregation config")
+		}
+		go cds.RunSymptomReportJob(jobCtx, interval, days)
+		log.WithField("prefix", "init").Info("Enabled symptom check-in aggregation")
+	}
+
+	if err := cds.SetAnomalyDetection(anomalyConfig); err != nil {
+		log.Panic(err)
+	}
+	go cds.NotificationWorker().Run(jobCtx)
+
+	if anomalyConfig.Enabled {
+		go cds.AnomalyDetectionWorker().Run(jobCtx)
+		log.WithField("prefix", "init").Info("Enabled symptom anomaly detection")
+	}
+
+	if err := cds.SetPOINotification(poiNotificationConfig); err != nil {
+		log.Panic(err)
+	}
+	if poiNotificationConfig.Enabled {
+		go cds.POIScoreCheckWorker().Run(jobCtx)
+		log.WithField("prefix", "init").Info("Enabled poi score change notification")
+	}
 
 	// Init http server
 	server = web.NewServer(viper.GetBool("server.tracing"), acct.(*account.AccountV2), viper.GetString("server.endpoint"), rootKey)
 	server.Middleware(server.DumpRequest)
 	server.Route("PUT", "/poi_rating/:poi_id", server.CheckMacaroon(), cds.SetPOIRating())
 	server.Route("GET", "/poi_rating/:poi_id", server.CheckMacaroon(), cds.GetPOISummarizedRatings)
+	server.Route("PUT", "/service/poi_rating/:poi_id", server.CheckServiceToken(viper.GetString("server.service_token")), cds.SetPOIRating())
 	server.Route("GET", "/poi_rating", server.CheckMacaroon(), cds.GetPOISummarizedRatings)
+	server.Route("GET", "/notification-preferences", server.CheckMacaroon(), cds.GetNotificationPreference)
+	server.Route("PUT", "/notification-preferences", server.CheckMacaroon(), cds.SetNotificationPreference)
+	server.Route("PUT", "/pois/:poi_id", server.CheckMacaroon(), server.CheckAdmin(viper.GetStringSlice("server.admin_accounts")), cds.AddPOI)
+	server.Route("GET", "/pois/nearby", server.CheckMacaroon(), cds.GetNearbyPOIs)
+	server.Route("GET", "/pois/top", server.CheckMacaroon(), cds.GetTopPOIs)
+	server.Route("GET", "/pois/trending", server.CheckMacaroon(), cds.GetTrendingPOIs)
+	server.Route("PUT", "/service/symptom_checkins/:date", server.CheckServiceToken(viper.GetString("server.service_token")), cds.SetSymptomCheckin)
 	server.Route("POST", "/symptom-daily-reports", server.CheckMacaroon(), cds.AddSymptomDailyReports)
+	server.Route("GET", "/admin/symptom-report-batches", server.CheckMacaroon(), server.CheckAdmin(viper.GetStringSlice("server.admin_accounts")), cds.ListSymptomReportBatches)
+	server.Route("POST", "/admin/symptom-report-batches/:batch_id/rollback", server.CheckMacaroon(), server.CheckAdmin(viper.GetStringSlice("server.admin_accounts")), cds.RollbackSymptomReportBatch)
+	server.Route("GET", "/admin/notifications", server.CheckMacaroon(), server.CheckAdmin(viper.GetStringSlice("server.admin_accounts")), cds.ListNotifications)
+	server.Route("GET", "/admin/metrics", server.CheckMacaroon(), server.CheckAdmin(viper.GetStringSlice("server.admin_accounts")), gin.WrapH(expvar.Handler()))
 	server.Route("GET", "/report-items", server.CheckMacaroon(), cds.GetSymptomReportItems)
+	server.Route("GET", "/symptom-timeseries", server.CheckMacaroon(), cds.GetSymptomTimeseries)
+	server.Route("GET", "/symptom-alerts", server.CheckMacaroon(), cds.GetSymptomAlerts)
 	server.Route("GET", "/data/export", server.CheckMacaroon(), cds.ExportData)
+	server.Route("DELETE", "/data/delete", server.CheckMacaroon(), cds.DeleteData)
 	log.WithField("prefix", "init").Info("Initialized http server")
 
 	// Remove initial context
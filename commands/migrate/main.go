@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"encoding/hex"
 	"flag"
 	"fmt"
 	"log"
@@ -52,7 +53,15 @@ func main() {
 		log.Panicf("connect mongo database with error: %s", err)
 	}
 
-	if err := store.NewMongodbDataPool(mongoClient, viper.GetString("server.store_prefix")).InitCommunityStore(); err != nil {
+	pseudonymKey, err := hex.DecodeString(viper.GetString("server.pseudonym_key"))
+	if err != nil {
+		log.Panicf("decode pseudonym key with error: %s", err)
+	}
+
+	dataStorePool := store.NewMongodbDataPool(mongoClient, viper.GetString("server.store_prefix"))
+	dataStorePool.SetPseudonymKey(pseudonymKey)
+	dataStorePool.SetDefaultCohort(viper.GetString("symptom_report.default_cohort"))
+	if err := dataStorePool.InitCommunityStore(); err != nil {
 		log.Panicf("initiate community store with error: %s", err)
 	}
 }
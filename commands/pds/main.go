@@ -21,12 +21,14 @@ import (
 	bitmarksdk "github.com/bitmark-inc/bitmark-sdk-go"
 	"github.com/bitmark-inc/bitmark-sdk-go/account"
 	"github.com/bitmark-inc/data-store/pds"
+	"github.com/bitmark-inc/data-store/rating"
 	"github.com/bitmark-inc/data-store/store"
 	"github.com/bitmark-inc/data-store/web"
 )
 
 var (
-	server *web.Server
+	server        *web.Server
+	cancelWorkers context.CancelFunc
 )
 
 func initLog() {
@@ -84,6 +86,11 @@ func main() {
 			<-initialCtx.Done()
 		}
 
+		if cancelWorkers != nil {
+			log.Info("Stop background workers")
+			cancelWorkers()
+		}
+
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 
@@ -136,13 +143,49 @@ func main() {
 		log.Panic(err)
 	}
 
-	pds := pds.New(store.NewMongodbDataPool(mongoClient, viper.GetString("server.store_prefix")))
+	documentSchemas, err := pds.LoadDocumentSchemas(viper.GetString("resources.schema_dir"))
+	if err != nil {
+		log.Panic(err)
+	}
+
+	dataStorePool := store.NewMongodbDataPool(mongoClient, viper.GetString("server.store_prefix"))
+	if err := dataStorePool.InitOutboxStore(); err != nil {
+		log.Panicf("initiate outbox store with error: %s", err)
+	}
+
+	communityClient := pds.NewCommunityClient(viper.GetString("contribution.cds_endpoint"), viper.GetString("contribution.service_token"))
+	pds := pds.New(dataStorePool, documentSchemas)
+
+	var ratingSchema rating.Schema
+	if err := viper.UnmarshalKey("poi_rating.schema", &ratingSchema); err != nil {
+		log.Panic(err)
+	}
+	pds.SetRatingSchema(ratingSchema)
+
+	var workerCtx context.Context
+	workerCtx, cancelWorkers = context.WithCancel(context.Background())
+	if viper.GetBool("contribution.enabled") {
+		pds.EnableContribution(communityClient)
+		for _, worker := range pds.ContributionWorkers() {
+			go worker.Run(workerCtx)
+		}
+		log.WithField("prefix", "init").Info("Enabled contribution to the community data store")
+	}
 
 	// Init http server
 	server = web.NewServer(viper.GetBool("server.tracing"), acct.(*account.AccountV2), viper.GetString("server.endpoint"), rootKey)
 	server.Middleware(server.DumpRequest)
 	server.Route("PUT", "/poi_rating/:poi_id", server.CheckMacaroon(), pds.RatePOIResource())
 	server.Route("GET", "/poi_rating/:poi_id", server.CheckMacaroon(), pds.GetPOIResource())
+	server.Route("GET", "/poi_rating_history/:poi_id", server.CheckMacaroon(), pds.GetPOIRatingHistory)
+	server.Route("GET", "/resources/:name", server.CheckMacaroon(), pds.ListDocuments)
+	server.Route("PUT", "/resources/:name/:id", server.CheckMacaroon(), pds.PutDocument)
+	server.Route("GET", "/resources/:name/:id", server.CheckMacaroon(), pds.GetDocument)
+	server.Route("DELETE", "/resources/:name/:id", server.CheckMacaroon(), pds.DeleteDocument)
+	server.Route("PUT", "/symptom_checkins/:date", server.CheckMacaroon(), pds.SetSymptomCheckin)
+	server.Route("GET", "/symptom_checkins", server.CheckMacaroon(), pds.GetSymptomCheckins)
+	server.Route("PUT", "/consents/:name", server.CheckMacaroon(), pds.SetConsent)
+	server.Route("GET", "/consents/:name", server.CheckMacaroon(), pds.GetConsent)
 	server.Route("GET", "/data/export", server.CheckMacaroon(), pds.ExportData)
 	server.Route("DELETE", "/data/delete", server.CheckMacaroon(), pds.DeleteData)
 
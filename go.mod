@@ -28,6 +28,7 @@ require (
 	github.com/tbalthazar/onesignal-go v0.0.0-20160928064723-312530be66c8
 	github.com/x-cray/logrus-prefixed-formatter v0.5.2
 	github.com/xdg/stringprep v1.0.0 // indirect
+	github.com/xeipuuv/gojsonschema v1.2.0
 	go.mongodb.org/mongo-driver v1.3.4
 	golang.org/x/crypto v0.0.0-20200602180216-279210d13fed // indirect
 	golang.org/x/net v0.0.0-20200602114024-627f9648deb9 // indirect
@@ -1,12 +1,23 @@
 package notification
 
-import "github.com/tbalthazar/onesignal-go"
+import (
+	"fmt"
 
+	"github.com/tbalthazar/onesignal-go"
+)
+
+// MaxExternalUserIDs is the maximum number of users a notification is sent to by external user ids
+const MaxExternalUserIDs = 2000
+
+var _ Notifier = (*Client)(nil)
+
+// Client sends notifications through OneSignal
 type Client struct {
 	appID           string
 	onesignalClient *onesignal.Client
 }
 
+// NewClient returns a OneSignal client of an app
 func NewClient(appID, appKey string) *Client {
 	client := onesignal.NewClient(nil)
 	client.AppKey = appKey
@@ -16,6 +27,8 @@ func NewClient(appID, appKey string) *Client {
 		onesignalClient: client,
 	}
 }
+
+// NotifyActiveUsers sends a notification to the Active Users segment
 func (c *Client) NotifyActiveUsers(headings, contents map[string]string) error {
 	req := &onesignal.NotificationRequest{
 		AppID:            c.appID,
@@ -26,3 +39,32 @@ func (c *Client) NotifyActiveUsers(headings, contents map[string]string) error {
 	_, _, err := c.onesignalClient.Notifications.Create(req)
 	return err
 }
+
+// targetedNotificationRequest adds the targeting by external user ids, which the
+// OneSignal client does not support, to a notification request
+type targetedNotificationRequest struct {
+	onesignal.NotificationRequest
+	IncludeExternalUserIDs []string `json:"include_external_user_ids"`
+}
+
+// NotifyUsers sends a notification to the devices of external user ids
+func (c *Client) NotifyUsers(externalUserIDs []string, headings, contents map[string]string) error {
+	if len(externalUserIDs) > MaxExternalUserIDs {
+		return fmt.Errorf("a notification targets at most %d users", MaxExternalUserIDs)
+	}
+
+	req, err := c.onesignalClient.NewRequest("POST", "/notifications", &targetedNotificationRequest{
+		NotificationRequest: onesignal.NotificationRequest{
+			AppID:    c.appID,
+			Headings: headings,
+			Contents: contents,
+		},
+		IncludeExternalUserIDs: externalUserIDs,
+	}, onesignal.APP)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.onesignalClient.Do(req, &onesignal.NotificationCreateResponse{})
+	return err
+}
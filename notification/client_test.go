@@ -0,0 +1,33 @@
+package notification
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientNotifyUsers(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/notifications", r.URL.Path)
+		assert.Equal(t, "Basic key", r.Header.Get("Authorization"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.Write([]byte(`{"id": "notification", "recipients": 2}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("app", "key")
+	client.onesignalClient.BaseURL, _ = url.Parse(server.URL)
+
+	assert.NoError(t, client.NotifyUsers([]string{"user1", "user2"}, nil, map[string]string{"en": "content"}))
+	assert.Equal(t, "app", body["app_id"])
+	assert.Equal(t, []interface{}{"user1", "user2"}, body["include_external_user_ids"])
+	assert.Equal(t, map[string]interface{}{"en": "content"}, body["contents"])
+	assert.NotContains(t, body, "included_segments")
+
+	assert.Error(t, client.NotifyUsers(make([]string, MaxExternalUserIDs+1), nil, map[string]string{"en": "content"}))
+}
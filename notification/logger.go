@@ -0,0 +1,24 @@
+package notification
+
+import (
+	log "github.com/sirupsen/logrus"
+)
+
+// Logger logs notifications instead of sending them. It is used in development.
+type Logger struct{}
+
+var _ Notifier = Logger{}
+
+// NotifyActiveUsers logs a notification
+func (Logger) NotifyActiveUsers(headings, contents map[string]string) error {
+	log.WithField("prefix", "notification").WithField("headings", headings).
+		WithField("contents", contents).Info("notify active users")
+	return nil
+}
+
+// NotifyUsers logs a notification to users
+func (Logger) NotifyUsers(externalUserIDs []string, headings, contents map[string]string) error {
+	log.WithField("prefix", "notification").WithField("external_user_ids", externalUserIDs).
+		WithField("headings", headings).WithField("contents", contents).Info("notify users")
+	return nil
+}
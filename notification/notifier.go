@@ -0,0 +1,19 @@
+package notification
+
+// Notifier sends push notifications to users of the app
+type Notifier interface {
+	// NotifyActiveUsers sends a notification to all active users. Headings and contents are
+	// given by languages.
+	NotifyActiveUsers(headings, contents map[string]string) error
+	// NotifyUsers sends a notification to the users of external user ids, which the app
+	// registers to the provider. Headings and contents are given by languages.
+	NotifyUsers(externalUserIDs []string, headings, contents map[string]string) error
+}
+
+// Notification is a notification sent to users
+type Notification struct {
+	// ExternalUserIDs are the users who are notified. All active users are notified if it is empty.
+	ExternalUserIDs []string
+	Headings        map[string]string
+	Contents        map[string]string
+}
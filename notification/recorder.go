@@ -0,0 +1,53 @@
+package notification
+
+import "sync"
+
+// Recorder keeps notifications in memory instead of sending them. It is used in tests.
+type Recorder struct {
+	// Err is returned by the notify methods if it is set, and the notification is not recorded
+	Err error
+
+	mutex         sync.Mutex
+	notifications []Notification
+}
+
+var _ Notifier = (*Recorder)(nil)
+
+// NewRecorder returns an empty recorder
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// NotifyActiveUsers records a notification
+func (r *Recorder) NotifyActiveUsers(headings, contents map[string]string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.Err != nil {
+		return r.Err
+	}
+
+	r.notifications = append(r.notifications, Notification{Headings: headings, Contents: contents})
+	return nil
+}
+
+// NotifyUsers records a notification to users
+func (r *Recorder) NotifyUsers(externalUserIDs []string, headings, contents map[string]string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.Err != nil {
+		return r.Err
+	}
+
+	r.notifications = append(r.notifications, Notification{ExternalUserIDs: externalUserIDs, Headings: headings, Contents: contents})
+	return nil
+}
+
+// Notifications returns the recorded notifications in the order they are sent
+func (r *Recorder) Notifications() []Notification {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return append([]Notification(nil), r.notifications...)
+}
@@ -0,0 +1,22 @@
+package notification
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecorder(t *testing.T) {
+	r := NewRecorder()
+	assert.Empty(t, r.Notifications())
+
+	headings := map[string]string{"en": "heading"}
+	contents := map[string]string{"en": "content"}
+	assert.NoError(t, r.NotifyActiveUsers(headings, contents))
+	assert.Equal(t, []Notification{{Headings: headings, Contents: contents}}, r.Notifications())
+
+	r.Err = errors.New("unavailable")
+	assert.Equal(t, r.Err, r.NotifyActiveUsers(headings, contents))
+	assert.Len(t, r.Notifications(), 1)
+}
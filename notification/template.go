@@ -0,0 +1,131 @@
+package notification
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// DefaultLanguage is the language which every template must have contents in
+const DefaultLanguage = "en"
+
+var templateFuncs = template.FuncMap{
+	"join": strings.Join,
+}
+
+// Template renders the headings and contents of a notification in languages
+type Template struct {
+	headings map[string]*template.Template
+	contents map[string]*template.Template
+}
+
+// ParseTemplate parses the text/template sources of headings and contents by languages
+func ParseTemplate(name string, headings, contents map[string]string) (*Template, error) {
+	if _, ok := contents[DefaultLanguage]; !ok {
+		return nil, fmt.Errorf("template %s has no contents in %s", name, DefaultLanguage)
+	}
+
+	t := &Template{
+		headings: make(map[string]*template.Template),
+		contents: make(map[string]*template.Template),
+	}
+	for _, part := range []struct {
+		sources   map[string]string
+		templates map[string]*template.Template
+	}{
+		{headings, t.headings},
+		{contents, t.contents},
+	} {
+		for language, source := range part.sources {
+			parsed, err := template.New(name + "." + language).Funcs(templateFuncs).Option("missingkey=error").Parse(source)
+			if err != nil {
+				return nil, err
+			}
+			part.templates[language] = parsed
+		}
+	}
+
+	return t, nil
+}
+
+// Render executes the template of each language with data
+func (t *Template) Render(data interface{}) (Notification, error) {
+	headings, err := render(t.headings, data)
+	if err != nil {
+		return Notification{}, err
+	}
+
+	contents, err := render(t.contents, data)
+	if err != nil {
+		return Notification{}, err
+	}
+
+	return Notification{Headings: headings, Contents: contents}, nil
+}
+
+func render(templates map[string]*template.Template, data interface{}) (map[string]string, error) {
+	texts := make(map[string]string, len(templates))
+	for language, t := range templates {
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, data); err != nil {
+			return nil, err
+		}
+		texts[language] = strings.TrimSpace(buf.String())
+	}
+	return texts, nil
+}
+
+// Templates are notification templates by names
+type Templates map[string]*Template
+
+// Lookup returns the template of the first name found
+func (t Templates) Lookup(names ...string) (*Template, bool) {
+	for _, name := range names {
+		if template, ok := t[name]; ok {
+			return template, true
+		}
+	}
+	return nil, false
+}
+
+// LoadTemplates loads templates from the JSON files in a directory. A file has the headings
+// and contents by languages, and the name of a template is the file name without the extension.
+func LoadTemplates(dir string) (Templates, error) {
+	templates := Templates{}
+	if dir == "" {
+		return templates, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		var sources struct {
+			Headings map[string]string `json:"headings"`
+			Contents map[string]string `json:"contents"`
+		}
+		if err := json.Unmarshal(data, &sources); err != nil {
+			return nil, fmt.Errorf("invalid template %s: %s", file, err)
+		}
+
+		name := strings.TrimSuffix(filepath.Base(file), ".json")
+		template, err := ParseTemplate(name, sources.Headings, sources.Contents)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template %s: %s", file, err)
+		}
+		templates[name] = template
+	}
+
+	return templates, nil
+}
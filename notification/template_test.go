@@ -0,0 +1,87 @@
+package notification
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplateRender(t *testing.T) {
+	template, err := ParseTemplate("alert",
+		map[string]string{"en": "Alert", "zh-Hant": "警示"},
+		map[string]string{"en": ` Reports of {{join .Symptoms ", "}} on {{.Date}} `})
+	assert.NoError(t, err)
+
+	n, err := template.Render(struct {
+		Date     string
+		Symptoms []string
+	}{"2020-07-21", []string{"Cough", "Fever"}})
+	assert.NoError(t, err)
+	assert.Equal(t, Notification{
+		Headings: map[string]string{"en": "Alert", "zh-Hant": "警示"},
+		Contents: map[string]string{"en": "Reports of Cough, Fever on 2020-07-21"},
+	}, n)
+
+	_, err = template.Render(map[string]string{"Date": "2020-07-21"})
+	assert.Error(t, err)
+
+	_, err = ParseTemplate("alert", nil, map[string]string{"zh-Hant": "警示"})
+	assert.Error(t, err)
+	_, err = ParseTemplate("alert", nil, map[string]string{"en": "{{.Date"})
+	assert.Error(t, err)
+}
+
+func TestLoadTemplates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "templates")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "new_report.json"),
+		[]byte(`{"headings": {"en": "New Data"}, "contents": {"en": "New reports of {{.Date}}"}}`), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "new_report.davis.json"),
+		[]byte(`{"contents": {"en": "New Davis reports"}}`), 0644))
+
+	templates, err := LoadTemplates(dir)
+	assert.NoError(t, err)
+	assert.Len(t, templates, 2)
+
+	template, ok := templates.Lookup("new_report.berkeley", "new_report")
+	assert.True(t, ok)
+	n, err := template.Render(struct{ Date string }{"2020-07-21"})
+	assert.NoError(t, err)
+	assert.Equal(t, "New reports of 2020-07-21", n.Contents["en"])
+
+	template, ok = templates.Lookup("new_report.davis", "new_report")
+	assert.True(t, ok)
+	n, err = template.Render(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "New Davis reports", n.Contents["en"])
+
+	_, ok = templates.Lookup("symptom_alert")
+	assert.False(t, ok)
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "symptom_alert.json"), []byte(`{"headings": {"en": "Alert"}}`), 0644))
+	_, err = LoadTemplates(dir)
+	assert.Error(t, err)
+}
+
+func TestLoadSampleTemplates(t *testing.T) {
+	templates, err := LoadTemplates("../commands/cds/notification_templates")
+	assert.NoError(t, err)
+	for _, name := range []string{"new_report", "symptom_alert", "poi_score_change"} {
+		template, ok := templates.Lookup(name)
+		assert.True(t, ok)
+		_, err := template.Render(struct {
+			Cohort        string
+			Date          string
+			TopSymptom    string
+			Symptoms      []string
+			Score         float64
+			PreviousScore float64
+		}{"UC Berkeley Safe Campus Study", "2020-07-21", "Cough", []string{"Cough"}, 3.6, 3})
+		assert.NoError(t, err)
+	}
+}
@@ -0,0 +1,125 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/bitmark-inc/data-store/store"
+)
+
+// HandlerFunc delivers a message
+type HandlerFunc func(ctx context.Context, msg *store.OutboxMessage) error
+
+// permanentError is an error which retrying does not help
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string {
+	return e.err.Error()
+}
+
+// Permanent wraps an error to stop retrying the delivery of a message
+func Permanent(err error) error {
+	return permanentError{err}
+}
+
+// Worker delivers messages of a kind from the outbox with exponential backoff
+type Worker struct {
+	Store   store.OutboxStore
+	Kind    string
+	Handler HandlerFunc
+
+	// PollInterval is the time to wait when there is no message to deliver
+	PollInterval time.Duration
+	// Lease is the time a claimed message is hidden from other workers
+	Lease time.Duration
+	// BaseDelay is the delay before the first retry, which doubles for each further retry
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between retries
+	MaxDelay time.Duration
+	// MaxAttempts is the number of attempts before giving up a message
+	MaxAttempts int
+}
+
+// NewWorker returns a worker with default settings
+func NewWorker(s store.OutboxStore, kind string, handler HandlerFunc) *Worker {
+	return &Worker{
+		Store:        s,
+		Kind:         kind,
+		Handler:      handler,
+		PollInterval: 5 * time.Second,
+		Lease:        time.Minute,
+		BaseDelay:    10 * time.Second,
+		MaxDelay:     time.Hour,
+		MaxAttempts:  12,
+	}
+}
+
+// Backoff returns the delay before the next attempt after the given number of attempts
+func (w *Worker) Backoff(attempts int) time.Duration {
+	delay := w.BaseDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= w.MaxDelay {
+			return w.MaxDelay
+		}
+	}
+	return delay
+}
+
+// Run delivers messages until the context is done
+func (w *Worker) Run(ctx context.Context) {
+	logger := log.WithField("prefix", "outbox").WithField("kind", w.Kind)
+	logger.Info("outbox worker started")
+
+	for {
+		delivered, err := w.deliverNext(ctx)
+		if err != nil {
+			logger.WithError(err).Error("fail to deliver outbox message")
+		}
+
+		if delivered {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			logger.Info("outbox worker stopped")
+			return
+		case <-time.After(w.PollInterval):
+		}
+	}
+}
+
+// deliverNext delivers a due message. It returns false if there is no due message.
+func (w *Worker) deliverNext(ctx context.Context) (bool, error) {
+	msg, err := w.Store.Claim(ctx, w.Kind, w.Lease)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return false, nil
+		}
+		return false, err
+	}
+
+	err = w.Handler(ctx, msg)
+	switch {
+	case err == nil:
+		return true, w.Store.MarkDelivered(ctx, msg)
+	case IsPermanent(err) || msg.Attempts >= w.MaxAttempts:
+		log.WithField("prefix", "outbox").WithField("kind", w.Kind).WithError(err).
+			WithField("attempts", msg.Attempts).Warn("give up outbox message")
+		return true, w.Store.MarkFailed(ctx, msg, err.Error())
+	default:
+		return true, w.Store.MarkRetry(ctx, msg, time.Now().Add(w.Backoff(msg.Attempts)), err.Error())
+	}
+}
+
+// IsPermanent reports whether an error stops retrying the delivery of a message
+func IsPermanent(err error) bool {
+	_, ok := err.(permanentError)
+	return ok
+}
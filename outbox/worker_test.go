@@ -0,0 +1,133 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/bitmark-inc/data-store/store"
+)
+
+// memoryOutboxStore keeps a single message for worker tests
+type memoryOutboxStore struct {
+	msg     *store.OutboxMessage
+	retryAt time.Time
+}
+
+func (m *memoryOutboxStore) Enqueue(ctx context.Context, msg store.OutboxMessage) error {
+	msg.Status = store.OutboxStatusPending
+	m.msg = &msg
+	return nil
+}
+
+func (m *memoryOutboxStore) Claim(ctx context.Context, kind string, lease time.Duration) (*store.OutboxMessage, error) {
+	if m.msg == nil || m.msg.Kind != kind || m.msg.Status != store.OutboxStatusPending {
+		return nil, mongo.ErrNoDocuments
+	}
+	m.msg.Attempts++
+	claimed := *m.msg
+	return &claimed, nil
+}
+
+func (m *memoryOutboxStore) MarkDelivered(ctx context.Context, msg *store.OutboxMessage) error {
+	m.msg.Status = store.OutboxStatusDelivered
+	return nil
+}
+
+func (m *memoryOutboxStore) MarkRetry(ctx context.Context, msg *store.OutboxMessage, retryAt time.Time, reason string) error {
+	m.msg.LastError = reason
+	m.retryAt = retryAt
+	return nil
+}
+
+func (m *memoryOutboxStore) MarkFailed(ctx context.Context, msg *store.OutboxMessage, reason string) error {
+	m.msg.Status = store.OutboxStatusFailed
+	m.msg.LastError = reason
+	return nil
+}
+
+func (m *memoryOutboxStore) List(ctx context.Context, kind, status string, limit int64) ([]store.OutboxMessage, error) {
+	return []store.OutboxMessage{*m.msg}, nil
+}
+
+func (m *memoryOutboxStore) DeletePending(ctx context.Context, kind, owner string) error {
+	m.msg = nil
+	return nil
+}
+
+func TestWorkerBackoff(t *testing.T) {
+	w := NewWorker(nil, "test", nil)
+	w.BaseDelay = time.Second
+	w.MaxDelay = 10 * time.Second
+
+	assert.Equal(t, time.Second, w.Backoff(1))
+	assert.Equal(t, 2*time.Second, w.Backoff(2))
+	assert.Equal(t, 8*time.Second, w.Backoff(4))
+	assert.Equal(t, 10*time.Second, w.Backoff(5))
+	assert.Equal(t, 10*time.Second, w.Backoff(100))
+}
+
+func TestWorkerDeliverNext(t *testing.T) {
+	ctx := context.Background()
+	s := &memoryOutboxStore{}
+	failures := 2
+	w := NewWorker(s, "test", func(ctx context.Context, msg *store.OutboxMessage) error {
+		if failures > 0 {
+			failures--
+			return fmt.Errorf("unavailable")
+		}
+		return nil
+	})
+
+	delivered, err := w.deliverNext(ctx)
+	assert.NoError(t, err)
+	assert.False(t, delivered)
+
+	assert.NoError(t, s.Enqueue(ctx, store.OutboxMessage{Kind: "test"}))
+
+	before := time.Now()
+	delivered, err = w.deliverNext(ctx)
+	assert.NoError(t, err)
+	assert.True(t, delivered)
+	assert.Equal(t, store.OutboxStatusPending, s.msg.Status)
+	assert.Equal(t, "unavailable", s.msg.LastError)
+	assert.True(t, !s.retryAt.Before(before.Add(w.BaseDelay)))
+
+	_, err = w.deliverNext(ctx)
+	assert.NoError(t, err)
+	_, err = w.deliverNext(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, store.OutboxStatusDelivered, s.msg.Status)
+	assert.Equal(t, 3, s.msg.Attempts)
+}
+
+func TestWorkerGiveUp(t *testing.T) {
+	ctx := context.Background()
+
+	s := &memoryOutboxStore{}
+	w := NewWorker(s, "test", func(ctx context.Context, msg *store.OutboxMessage) error {
+		return Permanent(fmt.Errorf("rejected"))
+	})
+	assert.NoError(t, s.Enqueue(ctx, store.OutboxMessage{Kind: "test"}))
+	_, err := w.deliverNext(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, store.OutboxStatusFailed, s.msg.Status)
+	assert.Equal(t, "rejected", s.msg.LastError)
+
+	s = &memoryOutboxStore{}
+	w = NewWorker(s, "test", func(ctx context.Context, msg *store.OutboxMessage) error {
+		return fmt.Errorf("unavailable")
+	})
+	w.MaxAttempts = 2
+	assert.NoError(t, s.Enqueue(ctx, store.OutboxMessage{Kind: "test"}))
+	_, err = w.deliverNext(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, store.OutboxStatusPending, s.msg.Status)
+	_, err = w.deliverNext(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, store.OutboxStatusFailed, s.msg.Status)
+}
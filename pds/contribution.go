@@ -0,0 +1,224 @@
+package pds
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bitmark-inc/data-store/outbox"
+	"github.com/bitmark-inc/data-store/store"
+)
+
+const (
+	// OutboxKindPOIRating is the kind of outbox messages which contribute POI ratings to the CDS
+	OutboxKindPOIRating = "poi_rating"
+	// OutboxKindSymptomCheckin is the kind of outbox messages which contribute symptom check-ins to the CDS
+	OutboxKindSymptomCheckin = "symptom_checkin"
+)
+
+// consentOutboxKinds maps known consents to the kinds of outbox messages they allow
+var consentOutboxKinds = map[string]string{
+	store.ConsentContributePOIRatings:      OutboxKindPOIRating,
+	store.ConsentContributeSymptomCheckins: OutboxKindSymptomCheckin,
+}
+
+// POIRatingContribution is a POI rating to be contributed to the CDS
+type POIRatingContribution struct {
+	AccountNumber string             `bson:"account_number"`
+	POIID         string             `bson:"poi_id"`
+	Ratings       map[string]float64 `bson:"ratings"`
+}
+
+// SymptomCheckinContribution is a symptom check-in to be contributed to the CDS
+type SymptomCheckinContribution struct {
+	AccountNumber string               `bson:"account_number"`
+	Checkin       store.SymptomCheckin `bson:"checkin"`
+}
+
+// CommunityClient calls the CDS on behalf of accounts with a service token
+type CommunityClient struct {
+	endpoint   string
+	token      string
+	httpClient *http.Client
+}
+
+func NewCommunityClient(endpoint, token string) *CommunityClient {
+	return &CommunityClient{
+		endpoint:   strings.TrimRight(endpoint, "/"),
+		token:      token,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// SetPOIRating sets the rating of a POI from an account in the CDS
+func (c *CommunityClient) SetPOIRating(ctx context.Context, accountNumber, poiID string, ratings map[string]float64) error {
+	return c.put(ctx, accountNumber, "/service/poi_rating/"+url.PathEscape(poiID), map[string]interface{}{"ratings": ratings})
+}
+
+// SetSymptomCheckin sets the symptom check-in of a day from an account in the CDS
+func (c *CommunityClient) SetSymptomCheckin(ctx context.Context, accountNumber string, checkin store.SymptomCheckin) error {
+	return c.put(ctx, accountNumber, "/service/symptom_checkins/"+url.PathEscape(checkin.Date), map[string]interface{}{"symptoms": checkin.Symptoms})
+}
+
+// put sends a request to a service path of the CDS on behalf of an account
+func (c *CommunityClient) put(ctx context.Context, accountNumber, path string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("PUT", c.endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("X-ACCOUNT-NUMBER", accountNumber)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		// the CDS rejects the contribution, which will not change by retrying
+		return outbox.Permanent(fmt.Errorf("cds rejects the contribution with status %d", resp.StatusCode))
+	default:
+		return fmt.Errorf("cds responds with status %d", resp.StatusCode)
+	}
+}
+
+// EnableContribution makes the PDS contribute POI ratings of consenting accounts to the CDS
+func (p *PDS) EnableContribution(client *CommunityClient) {
+	p.communityClient = client
+}
+
+// ContributionWorkers returns workers which deliver contributions in the outbox to the CDS
+func (p *PDS) ContributionWorkers() []*outbox.Worker {
+	return []*outbox.Worker{
+		outbox.NewWorker(p.dataStorePool.Outbox(), OutboxKindPOIRating, func(ctx context.Context, msg *store.OutboxMessage) error {
+			var contribution POIRatingContribution
+			if err := msg.DecodePayload(&contribution); err != nil {
+				return outbox.Permanent(err)
+			}
+
+			return p.communityClient.SetPOIRating(ctx, contribution.AccountNumber, contribution.POIID, contribution.Ratings)
+		}),
+		outbox.NewWorker(p.dataStorePool.Outbox(), OutboxKindSymptomCheckin, func(ctx context.Context, msg *store.OutboxMessage) error {
+			var contribution SymptomCheckinContribution
+			if err := msg.DecodePayload(&contribution); err != nil {
+				return outbox.Permanent(err)
+			}
+
+			return p.communityClient.SetSymptomCheckin(ctx, contribution.AccountNumber, contribution.Checkin)
+		}),
+	}
+}
+
+// contributePOIRating puts a POI rating into the outbox if the account consents to contribute
+func (p *PDS) contributePOIRating(ctx context.Context, accountNumber, poiID string, ratings map[string]float64) error {
+	// only the latest rating of a POI is delivered if the previous one is still pending
+	return p.contribute(ctx, store.ConsentContributePOIRatings, accountNumber, poiID, POIRatingContribution{
+		AccountNumber: accountNumber,
+		POIID:         poiID,
+		Ratings:       ratings,
+	})
+}
+
+// contributeSymptomCheckin puts a symptom check-in into the outbox if the account consents to contribute
+func (p *PDS) contributeSymptomCheckin(ctx context.Context, accountNumber string, checkin store.SymptomCheckin) error {
+	// the time of the check-in is not contributed
+	checkin.Timestamp = 0
+	return p.contribute(ctx, store.ConsentContributeSymptomCheckins, accountNumber, checkin.Date, SymptomCheckinContribution{
+		AccountNumber: accountNumber,
+		Checkin:       checkin,
+	})
+}
+
+// contribute puts a contribution into the outbox if the account grants the consent. A pending
+// contribution of the same subject is replaced.
+func (p *PDS) contribute(ctx context.Context, consentName, accountNumber, subject string, payload interface{}) error {
+	if p.communityClient == nil {
+		return nil
+	}
+
+	consent, err := p.dataStorePool.Account(accountNumber).GetConsent(ctx, consentName)
+	if err != nil {
+		return err
+	}
+	if !consent.Granted {
+		return nil
+	}
+
+	kind := consentOutboxKinds[consentName]
+	msg, err := store.NewOutboxMessage(kind, accountNumber, fmt.Sprintf("%s:%s:%s", kind, accountNumber, subject), payload)
+	if err != nil {
+		return err
+	}
+
+	return p.dataStorePool.Outbox().Enqueue(ctx, msg)
+}
+
+func (p *PDS) SetConsent(c *gin.Context) {
+	accountNumber := c.GetString("account_number")
+	name := c.Param("name")
+
+	if _, ok := consentOutboxKinds[name]; !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "unknown consent"})
+		return
+	}
+
+	var params struct {
+		Granted *bool `json:"granted" binding:"required"`
+	}
+
+	if err := c.BindJSON(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := p.dataStorePool.Account(accountNumber).SetConsent(c, name, *params.Granted); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !*params.Granted {
+		// stop delivering contributions which are not delivered yet
+		if err := p.dataStorePool.Outbox().DeletePending(c, consentOutboxKinds[name], accountNumber); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"result": "ok"})
+}
+
+func (p *PDS) GetConsent(c *gin.Context) {
+	accountNumber := c.GetString("account_number")
+	name := c.Param("name")
+
+	if _, ok := consentOutboxKinds[name]; !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "unknown consent"})
+		return
+	}
+
+	consent, err := p.dataStorePool.Account(accountNumber).GetConsent(c, name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, consent)
+}
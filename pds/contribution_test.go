@@ -0,0 +1,67 @@
+package pds
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitmark-inc/data-store/outbox"
+	"github.com/bitmark-inc/data-store/store"
+)
+
+func TestCommunityClientSetPOIRating(t *testing.T) {
+	status := http.StatusOK
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "PUT", r.Method)
+		assert.Equal(t, "/service/poi_rating/poi-1", r.URL.Path)
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		assert.Equal(t, "account", r.Header.Get("X-ACCOUNT-NUMBER"))
+
+		var body struct {
+			Ratings map[string]float64 `json:"ratings"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, map[string]float64{"mask": 5}, body.Ratings)
+
+		w.WriteHeader(status)
+	}))
+	defer testServer.Close()
+
+	client := NewCommunityClient(testServer.URL+"/", "token")
+	ctx := context.Background()
+	assert.NoError(t, client.SetPOIRating(ctx, "account", "poi-1", map[string]float64{"mask": 5}))
+
+	status = http.StatusBadRequest
+	err := client.SetPOIRating(ctx, "account", "poi-1", map[string]float64{"mask": 5})
+	assert.Error(t, err)
+	assert.True(t, outbox.IsPermanent(err))
+
+	status = http.StatusBadGateway
+	err = client.SetPOIRating(ctx, "account", "poi-1", map[string]float64{"mask": 5})
+	assert.Error(t, err)
+	assert.False(t, outbox.IsPermanent(err))
+}
+
+func TestCommunityClientSetSymptomCheckin(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "PUT", r.Method)
+		assert.Equal(t, "/service/symptom_checkins/2020-07-21", r.URL.Path)
+		assert.Equal(t, "account", r.Header.Get("X-ACCOUNT-NUMBER"))
+
+		var body map[string]interface{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, map[string]interface{}{"symptoms": []interface{}{"cough"}}, body)
+	}))
+	defer testServer.Close()
+
+	client := NewCommunityClient(testServer.URL, "token")
+	assert.NoError(t, client.SetSymptomCheckin(context.Background(), "account", store.SymptomCheckin{
+		Date:      "2020-07-21",
+		Symptoms:  []string{"cough"},
+		Timestamp: 1000,
+	}))
+}
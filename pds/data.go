@@ -27,5 +27,12 @@ func (p *PDS) DeleteData(c *gin.Context) {
 		return
 	}
 
+	for _, kind := range consentOutboxKinds {
+		if err := p.dataStorePool.Outbox().DeletePending(c, kind, accountNumber); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{"result": "ok"})
 }
@@ -0,0 +1,160 @@
+package pds
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xeipuuv/gojsonschema"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/bitmark-inc/data-store/store"
+)
+
+var documentNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_\-]{1,64}$`)
+
+// LoadDocumentSchemas loads JSON schemas from a folder. Each file named `<collection>.json`
+// is used to validate documents of the collection.
+func LoadDocumentSchemas(dir string) (map[string]*gojsonschema.Schema, error) {
+	schemas := map[string]*gojsonschema.Schema{}
+	if dir == "" {
+		return schemas, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		schema, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid schema %s: %s", file, err)
+		}
+
+		schemas[strings.TrimSuffix(filepath.Base(file), ".json")] = schema
+	}
+
+	return schemas, nil
+}
+
+// documentParams returns the collection name and the document id of the path. The error response
+// is written if they are invalid. The id is not validated for routes without it.
+func documentParams(c *gin.Context, withID bool) (string, string, bool) {
+	collection := c.Param("name")
+	id := c.Param("id")
+
+	if !documentNamePattern.MatchString(collection) || (withID && !documentNamePattern.MatchString(id)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid collection name or document id"})
+		return "", "", false
+	}
+	return collection, id, true
+}
+
+func (p *PDS) PutDocument(c *gin.Context) {
+	accountNumber := c.GetString("account_number")
+	collection, id, ok := documentParams(c, true)
+	if !ok {
+		return
+	}
+
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := store.ValidateDocument(body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if schema, ok := p.documentSchemas[collection]; ok {
+		result, err := schema.Validate(gojsonschema.NewBytesLoader(body))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if !result.Valid() {
+			violations := make([]string, 0, len(result.Errors()))
+			for _, e := range result.Errors() {
+				violations = append(violations, e.String())
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": "document does not match the schema", "violations": violations})
+			return
+		}
+	}
+
+	if err := p.dataStorePool.Account(accountNumber).PutDocument(c, collection, id, body); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"result": "ok"})
+}
+
+func (p *PDS) GetDocument(c *gin.Context) {
+	accountNumber := c.GetString("account_number")
+	collection, id, ok := documentParams(c, true)
+	if !ok {
+		return
+	}
+
+	doc, err := p.dataStorePool.Account(accountNumber).GetDocument(c, collection, id)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"error": "document not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, doc)
+}
+
+func (p *PDS) ListDocuments(c *gin.Context) {
+	accountNumber := c.GetString("account_number")
+	collection, _, ok := documentParams(c, false)
+	if !ok {
+		return
+	}
+
+	docs, err := p.dataStorePool.Account(accountNumber).ListDocuments(c, collection)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"documents": docs})
+}
+
+func (p *PDS) DeleteDocument(c *gin.Context) {
+	accountNumber := c.GetString("account_number")
+	collection, id, ok := documentParams(c, true)
+	if !ok {
+		return
+	}
+
+	err := p.dataStorePool.Account(accountNumber).DeleteDocument(c, collection, id)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"error": "document not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"result": "ok"})
+}
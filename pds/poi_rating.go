@@ -5,6 +5,8 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/bitmark-inc/data-store/rating"
 )
 
 func (p *PDS) RatePOIResource() gin.HandlerFunc {
@@ -21,8 +23,13 @@ func (p *PDS) RatePOIResource() gin.HandlerFunc {
 			return
 		}
 
-		if params.Ratings == nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "no rating provided"})
+		if poiID == rating.SchemaPath {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid poi id"})
+			return
+		}
+
+		if violations := p.ratingSchema.Validate(params.Ratings); len(violations) > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ratings", "violations": violations})
 			return
 		}
 
@@ -32,6 +39,11 @@ func (p *PDS) RatePOIResource() gin.HandlerFunc {
 			return
 		}
 
+		if err := p.contributePOIRating(c, accountNumber, poiID, params.Ratings); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
 		c.JSON(http.StatusOK, gin.H{"result": "ok"})
 	}
 }
@@ -41,6 +53,12 @@ func (p *PDS) GetPOIResource() gin.HandlerFunc {
 		accountNumber := c.GetString("account_number")
 		poiID := c.Param("poi_id")
 
+		// the rating schema shares the route with POIs
+		if poiID == rating.SchemaPath {
+			p.GetPOIRatingSchema(c)
+			return
+		}
+
 		r, err := p.dataStorePool.Account(accountNumber).GetPOIRating(c, poiID)
 		if err != nil {
 			if err != mongo.ErrNoDocuments {
@@ -56,3 +74,20 @@ func (p *PDS) GetPOIResource() gin.HandlerFunc {
 		c.JSON(http.StatusOK, gin.H{"ratings": r})
 	}
 }
+
+func (p *PDS) GetPOIRatingHistory(c *gin.Context) {
+	accountNumber := c.GetString("account_number")
+	poiID := c.Param("poi_id")
+
+	history, err := p.dataStorePool.Account(accountNumber).GetPOIRatingHistory(c, poiID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"history": history})
+}
+
+func (p *PDS) GetPOIRatingSchema(c *gin.Context) {
+	c.JSON(http.StatusOK, p.ratingSchema)
+}
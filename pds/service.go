@@ -1,15 +1,27 @@
 package pds
 
 import (
+	"github.com/xeipuuv/gojsonschema"
+
+	"github.com/bitmark-inc/data-store/rating"
 	"github.com/bitmark-inc/data-store/store"
 )
 
 type PDS struct {
-	dataStorePool store.DataStorePool
+	dataStorePool   store.DataStorePool
+	documentSchemas map[string]*gojsonschema.Schema
+	ratingSchema    rating.Schema
+	communityClient *CommunityClient
 }
 
-func New(pool store.DataStorePool) *PDS {
+func New(pool store.DataStorePool, documentSchemas map[string]*gojsonschema.Schema) *PDS {
 	return &PDS{
-		dataStorePool: pool,
+		dataStorePool:   pool,
+		documentSchemas: documentSchemas,
 	}
 }
+
+// SetRatingSchema sets the schema which POI ratings are validated against
+func (p *PDS) SetRatingSchema(schema rating.Schema) {
+	p.ratingSchema = schema
+}
@@ -0,0 +1,84 @@
+package pds
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bitmark-inc/data-store/store"
+)
+
+const defaultSymptomCheckinDays = 14
+
+func (p *PDS) SetSymptomCheckin(c *gin.Context) {
+	accountNumber := c.GetString("account_number")
+
+	date, err := time.Parse(store.SymptomCheckinDateLayout, c.Param("date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date"})
+		return
+	}
+	// a day ahead is allowed for accounts in time zones ahead of UTC
+	if date.After(time.Now().UTC().AddDate(0, 0, 1)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "date is in the future"})
+		return
+	}
+
+	var params struct {
+		Symptoms []string `json:"symptoms"`
+	}
+
+	if err := c.BindJSON(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	checkin := store.SymptomCheckin{
+		Date:     date.Format(store.SymptomCheckinDateLayout),
+		Symptoms: store.NormalizeSymptoms(params.Symptoms),
+	}
+
+	if err := p.dataStorePool.Account(accountNumber).SetSymptomCheckin(c, checkin); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := p.contributeSymptomCheckin(c, accountNumber, checkin); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"result": "ok"})
+}
+
+func (p *PDS) GetSymptomCheckins(c *gin.Context) {
+	accountNumber := c.GetString("account_number")
+
+	var params struct {
+		Days int `form:"days"`
+	}
+
+	if err := c.BindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	days := defaultSymptomCheckinDays
+	if params.Days != 0 {
+		days = params.Days
+	}
+	if days < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid days"})
+		return
+	}
+
+	since := time.Now().UTC().AddDate(0, 0, 1-days).Format(store.SymptomCheckinDateLayout)
+	checkins, err := p.dataStorePool.Account(accountNumber).GetSymptomCheckins(c, since)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"checkins": checkins})
+}
@@ -0,0 +1,146 @@
+package privacy
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	// Laplace adds noise from the laplace distribution, which gives pure epsilon-DP
+	Laplace = "laplace"
+	// Gaussian adds noise from the normal distribution, which gives (epsilon, delta)-DP
+	Gaussian = "gaussian"
+)
+
+// Config configures the differential privacy mode of community statistics
+type Config struct {
+	// Mechanism is either laplace or gaussian. Empty disables the mode.
+	Mechanism string `mapstructure:"mechanism"`
+	// Epsilon is spent by each response, and split evenly across its statistics
+	Epsilon float64 `mapstructure:"epsilon"`
+	// Delta is only used by the gaussian mechanism
+	Delta float64 `mapstructure:"delta"`
+	// Budget is the total epsilon which can be spent on a dataset
+	Budget float64 `mapstructure:"budget"`
+	// BudgetPeriod replenishes the budget of a dataset after the period. Zero never replenishes it.
+	BudgetPeriod time.Duration `mapstructure:"budget_period"`
+}
+
+// Enabled reports whether the differential privacy mode is configured
+func (c Config) Enabled() bool {
+	return c.Mechanism != ""
+}
+
+// Validate checks whether the configuration gives a meaningful guarantee
+func (c Config) Validate() error {
+	switch c.Mechanism {
+	case "":
+		return nil
+	case Laplace:
+	case Gaussian:
+		if c.Delta <= 0 || c.Delta >= 1 {
+			return fmt.Errorf("delta must be between 0 and 1 for the gaussian mechanism")
+		}
+		if c.Epsilon >= 1 {
+			return fmt.Errorf("epsilon must be less than 1 for the gaussian mechanism")
+		}
+	default:
+		return fmt.Errorf("unknown mechanism %s", c.Mechanism)
+	}
+
+	if c.Epsilon <= 0 {
+		return fmt.Errorf("epsilon must be positive")
+	}
+	if c.Budget < c.Epsilon {
+		return fmt.Errorf("budget must not be less than epsilon")
+	}
+	if c.BudgetPeriod < 0 {
+		return fmt.Errorf("budget period must not be negative")
+	}
+	return nil
+}
+
+// Noise perturbs statistics with a share of the privacy loss of a response
+type Noise struct {
+	mechanism string
+	epsilon   float64
+	delta     float64
+
+	// uniform returns a random number in [0, 1)
+	uniform func() float64
+}
+
+// NewNoise returns the noise which spends the whole epsilon of the config on one statistic
+func NewNoise(c Config) *Noise {
+	return &Noise{
+		mechanism: c.Mechanism,
+		epsilon:   c.Epsilon,
+		delta:     c.Delta,
+		uniform:   secureUniform,
+	}
+}
+
+// Split returns the noise for one of n statistics released together. By sequential
+// composition, releasing all of them spends the epsilon and delta of n.
+func (n *Noise) Split(parts int) *Noise {
+	if parts <= 1 {
+		return n
+	}
+
+	split := *n
+	split.epsilon /= float64(parts)
+	split.delta /= float64(parts)
+	return &split
+}
+
+// Add perturbs a value whose change by a single contributor is bounded by the sensitivity
+func (n *Noise) Add(value, sensitivity float64) float64 {
+	if n.mechanism == Gaussian {
+		sigma := sensitivity * math.Sqrt(2*math.Log(1.25/n.delta)) / n.epsilon
+		return value + sigma*n.normal()
+	}
+
+	return value + n.laplace(sensitivity/n.epsilon)
+}
+
+// Count perturbs a count which a single contributor changes by at most one. The result
+// is rounded and never negative.
+func (n *Noise) Count(count int) int {
+	noised := math.Round(n.Add(float64(count), 1))
+	if noised < 0 {
+		return 0
+	}
+	return int(noised)
+}
+
+// laplace samples from the laplace distribution centered at zero
+func (n *Noise) laplace(scale float64) float64 {
+	u := n.uniform() - 0.5
+	for u == -0.5 {
+		u = n.uniform() - 0.5
+	}
+	if u < 0 {
+		return scale * math.Log(1+2*u)
+	}
+	return -scale * math.Log(1-2*u)
+}
+
+// normal samples from the standard normal distribution by the Box-Muller transform
+func (n *Noise) normal() float64 {
+	u1 := 1 - n.uniform()
+	u2 := n.uniform()
+	return math.Sqrt(-2*math.Log(u1)) * math.Cos(2*math.Pi*u2)
+}
+
+// secureUniform draws a uniform number from a cryptographic source, since noise drawn
+// from a predictable source can be removed
+func secureUniform() float64 {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	return float64(binary.BigEndian.Uint64(b[:])>>11) / (1 << 53)
+}
@@ -0,0 +1,74 @@
+package privacy
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigValidate(t *testing.T) {
+	assert.NoError(t, Config{}.Validate())
+	assert.False(t, Config{}.Enabled())
+	assert.NoError(t, Config{Mechanism: Laplace, Epsilon: 1, Budget: 10}.Validate())
+	assert.NoError(t, Config{Mechanism: Gaussian, Epsilon: 0.5, Delta: 1e-5, Budget: 10}.Validate())
+	assert.NoError(t, Config{Mechanism: Laplace, Epsilon: 1, Budget: 10, BudgetPeriod: 24 * time.Hour}.Validate())
+
+	assert.Error(t, Config{Mechanism: "uniform", Epsilon: 1, Budget: 10}.Validate())
+	assert.Error(t, Config{Mechanism: Laplace, Budget: 10}.Validate())
+	assert.Error(t, Config{Mechanism: Laplace, Epsilon: 1}.Validate())
+	assert.Error(t, Config{Mechanism: Gaussian, Epsilon: 0.5, Budget: 10}.Validate())
+	assert.Error(t, Config{Mechanism: Gaussian, Epsilon: 2, Delta: 1e-5, Budget: 10}.Validate())
+	assert.Error(t, Config{Mechanism: Laplace, Epsilon: 1, Budget: 10, BudgetPeriod: -time.Hour}.Validate())
+}
+
+func TestNoiseSplit(t *testing.T) {
+	n := NewNoise(Config{Mechanism: Gaussian, Epsilon: 0.5, Delta: 1e-5})
+	split := n.Split(5)
+	assert.InDelta(t, 0.1, split.epsilon, 1e-12)
+	assert.InDelta(t, 2e-6, split.delta, 1e-12)
+	assert.InDelta(t, 0.5, n.epsilon, 1e-12)
+	assert.Equal(t, n, n.Split(1))
+}
+
+func TestNoiseLaplace(t *testing.T) {
+	n := NewNoise(Config{Mechanism: Laplace, Epsilon: 0.5})
+
+	n.uniform = func() float64 { return 0.5 }
+	assert.Equal(t, 10.0, n.Add(10, 1))
+
+	// the quantile at 1 - e^-1 / 2 is one scale above the center
+	n.uniform = func() float64 { return 1 - math.Exp(-1)/2 }
+	assert.InDelta(t, 12.0, n.Add(10, 1), 1e-9)
+
+	n.uniform = func() float64 { return math.Exp(-1) / 2 }
+	assert.InDelta(t, 8.0, n.Add(10, 1), 1e-9)
+	assert.Equal(t, 0, n.Count(1))
+}
+
+func TestNoiseDistribution(t *testing.T) {
+	for _, c := range []Config{
+		{Mechanism: Laplace, Epsilon: 1},
+		{Mechanism: Gaussian, Epsilon: 0.5, Delta: 1e-5},
+	} {
+		n := NewNoise(c)
+
+		samples := 20000
+		sum, squares := 0.0, 0.0
+		for i := 0; i < samples; i++ {
+			v := n.Add(0, 1)
+			sum += v
+			squares += v * v
+		}
+		mean := sum / float64(samples)
+		variance := squares/float64(samples) - mean*mean
+
+		expected := 2.0 // 2b^2 of laplace with b = 1
+		if c.Mechanism == Gaussian {
+			expected = 2 * math.Log(1.25/c.Delta) / (c.Epsilon * c.Epsilon)
+		}
+		assert.InDelta(t, 0, mean, 0.1*math.Sqrt(expected), c.Mechanism)
+		assert.InDelta(t, expected, variance, 0.1*expected, c.Mechanism)
+	}
+}
@@ -0,0 +1,88 @@
+package rating
+
+import (
+	"fmt"
+	"sort"
+)
+
+// SchemaPath is the path element under /poi_rating which serves the rating schema
+// instead of a POI. No POI can be rated with this ID.
+const SchemaPath = "schema"
+
+// KeySchema describes a rating key that users are asked to rate
+type KeySchema struct {
+	Key         string  `json:"key" mapstructure:"key"`
+	Description string  `json:"description" mapstructure:"description"`
+	Min         float64 `json:"min" mapstructure:"min"`
+	Max         float64 `json:"max" mapstructure:"max"`
+	Required    bool    `json:"required" mapstructure:"required"`
+}
+
+// Schema describes the keys and values allowed in POI ratings.
+// An empty schema allows any key and value.
+type Schema struct {
+	Keys []KeySchema `json:"keys" mapstructure:"keys"`
+}
+
+// Bounds returns the range of values allowed for a key. It is not ok if the
+// key is not in the schema or the range is not given.
+func (s Schema) Bounds(key string) (min, max float64, ok bool) {
+	for _, k := range s.Keys {
+		if k.Key == key && k.Max > k.Min {
+			return k.Min, k.Max, true
+		}
+	}
+	return 0, 0, false
+}
+
+// Bounded reports whether any key of the schema has a range of values
+func (s Schema) Bounded() bool {
+	for _, k := range s.Keys {
+		if k.Max > k.Min {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate returns all violations of the ratings against the schema
+func (s Schema) Validate(ratings map[string]float64) []string {
+	violations := make([]string, 0)
+	if len(ratings) == 0 {
+		return append(violations, "no rating provided")
+	}
+
+	if len(s.Keys) == 0 {
+		return violations
+	}
+
+	known := make(map[string]struct{}, len(s.Keys))
+	for _, k := range s.Keys {
+		known[k.Key] = struct{}{}
+
+		v, ok := ratings[k.Key]
+		if !ok {
+			if k.Required {
+				violations = append(violations, fmt.Sprintf("%s is required", k.Key))
+			}
+			continue
+		}
+
+		if k.Max > k.Min && (v < k.Min || v > k.Max) {
+			violations = append(violations, fmt.Sprintf("%s must be between %g and %g", k.Key, k.Min, k.Max))
+		}
+	}
+
+	unknown := make([]string, 0)
+	for key := range ratings {
+		if _, ok := known[key]; !ok {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		violations = append(violations, fmt.Sprintf("%s is not an allowed key", key))
+	}
+
+	return violations
+}
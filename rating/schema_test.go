@@ -0,0 +1,56 @@
+package rating
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchemaValidate(t *testing.T) {
+	schema := Schema{
+		Keys: []KeySchema{
+			{Key: "mask", Min: 1, Max: 5, Required: true},
+			{Key: "distancing", Min: 1, Max: 5, Required: true},
+			{Key: "ventilation", Min: 1, Max: 5},
+		},
+	}
+
+	assert.Empty(t, schema.Validate(map[string]float64{"mask": 1, "distancing": 5}))
+	assert.Empty(t, schema.Validate(map[string]float64{"mask": 3, "distancing": 4, "ventilation": 2}))
+	assert.Equal(t, []string{"no rating provided"}, schema.Validate(nil))
+	assert.Equal(t, []string{
+		"mask must be between 1 and 5",
+		"distancing is required",
+		"a is not an allowed key",
+		"b is not an allowed key",
+	}, schema.Validate(map[string]float64{"mask": 6, "b": 1, "a": 2}))
+}
+
+func TestEmptySchemaValidate(t *testing.T) {
+	schema := Schema{}
+	assert.Empty(t, schema.Validate(map[string]float64{"anything": 100}))
+	assert.Equal(t, []string{"no rating provided"}, schema.Validate(map[string]float64{}))
+}
+
+func TestSchemaBounds(t *testing.T) {
+	schema := Schema{
+		Keys: []KeySchema{
+			{Key: "mask", Min: 1, Max: 5},
+			{Key: "comment"},
+		},
+	}
+
+	min, max, ok := schema.Bounds("mask")
+	assert.True(t, ok)
+	assert.Equal(t, 1.0, min)
+	assert.Equal(t, 5.0, max)
+
+	_, _, ok = schema.Bounds("comment")
+	assert.False(t, ok)
+	_, _, ok = schema.Bounds("unknown")
+	assert.False(t, ok)
+
+	assert.True(t, schema.Bounded())
+	assert.False(t, Schema{Keys: []KeySchema{{Key: "comment"}}}.Bounded())
+	assert.False(t, Schema{}.Bounded())
+}
@@ -0,0 +1,59 @@
+package store
+
+// suppress hides what is derived from less than k accounts, so that the rating of an
+// individual can not be learnt from the summary. A summary of a POI rated by less than
+// k accounts, or whose rating keys are all suppressed, is replaced by a suppressed one.
+// Otherwise, rating keys counted from less than k ratings are removed, and the average
+// ratings are recomputed from the kept keys only. The distribution of a key with a histogram
+// bucket counted from less than k ratings is removed as a whole, since the missing bucket
+// could be derived from the other buckets, the count and the standard deviation.
+func (s *POISummarizedRating) suppress(k int) {
+	if s.RatingCount < int64(k) {
+		s.clear()
+		return
+	}
+
+	for key, r := range s.Ratings {
+		if r.Counts < k {
+			delete(s.Ratings, key)
+			continue
+		}
+
+		for _, count := range r.Histogram {
+			if count < k {
+				r.Histogram = nil
+				r.StdDev = nil
+				r.ConfidenceInterval = nil
+				break
+			}
+		}
+		s.Ratings[key] = r
+	}
+
+	if len(s.Ratings) == 0 {
+		s.clear()
+		return
+	}
+
+	scoreSum, bayesianSum := 0.0, 0.0
+	for _, r := range s.Ratings {
+		scoreSum += r.Score
+		if r.BayesianScore != nil {
+			bayesianSum += *r.BayesianScore
+		}
+	}
+	s.AverageRating = scoreSum / float64(len(s.Ratings))
+	if s.BayesianAverageRating != nil {
+		avg := bayesianSum / float64(len(s.Ratings))
+		s.BayesianAverageRating = &avg
+	}
+}
+
+// clear replaces the summary with a suppressed one which has no statistics
+func (s *POISummarizedRating) clear() {
+	*s = POISummarizedRating{
+		ID:         s.ID,
+		Ratings:    map[string]RatingInfo{},
+		Suppressed: true,
+	}
+}
@@ -0,0 +1,57 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPOISummarizedRatingSuppress(t *testing.T) {
+	summary := POISummarizedRating{
+		ID:            "poi",
+		LastUpdated:   1000,
+		AverageRating: 4,
+		RatingCount:   2,
+		Ratings: map[string]RatingInfo{
+			"a": {Score: 4, Counts: 2},
+		},
+	}
+	summary.suppress(3)
+	assert.Equal(t, POISummarizedRating{ID: "poi", Ratings: map[string]RatingInfo{}, Suppressed: true}, summary)
+
+	stdDev, bayesianA, bayesianB, bayesianC := 0.5, 3.8, 2.5, 3.0
+	bayesianAverage := (bayesianA + bayesianB + bayesianC) / 3
+	summary = POISummarizedRating{
+		ID:                    "poi",
+		RatingCount:           5,
+		AverageRating:         3,
+		BayesianAverageRating: &bayesianAverage,
+		Ratings: map[string]RatingInfo{
+			"a": {Score: 4, Counts: 5, BayesianScore: &bayesianA, StdDev: &stdDev, ConfidenceInterval: &[2]float64{3, 5},
+				Histogram: map[string]int{"3": 1, "4": 4}},
+			"b": {Score: 2, Counts: 2, BayesianScore: &bayesianB},
+			"c": {Score: 3, Counts: 5, BayesianScore: &bayesianC, StdDev: &stdDev, Histogram: map[string]int{"3": 5}},
+		},
+	}
+	summary.suppress(3)
+	assert.False(t, summary.Suppressed)
+	// the distribution of a key with a small bucket is removed as a whole
+	assert.Equal(t, map[string]RatingInfo{
+		"a": {Score: 4, Counts: 5, BayesianScore: &bayesianA},
+		"c": {Score: 3, Counts: 5, BayesianScore: &bayesianC, StdDev: &stdDev, Histogram: map[string]int{"3": 5}},
+	}, summary.Ratings)
+	// the averages do not include the suppressed key
+	assert.Equal(t, 3.5, summary.AverageRating)
+	assert.InDelta(t, 3.4, *summary.BayesianAverageRating, 1e-9)
+
+	summary = POISummarizedRating{
+		ID:            "poi",
+		RatingCount:   5,
+		AverageRating: 2,
+		Ratings: map[string]RatingInfo{
+			"b": {Score: 2, Counts: 2},
+		},
+	}
+	summary.suppress(3)
+	assert.Equal(t, POISummarizedRating{ID: "poi", Ratings: map[string]RatingInfo{}, Suppressed: true}, summary)
+}
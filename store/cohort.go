@@ -0,0 +1,79 @@
+package store
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// cohortCollections are the collections of symptom reports which are partitioned by cohorts
+var cohortCollections = []string{"symptom_reports", "symptom_report_batches", "symptom_report_versions", "symptom_alerts"}
+
+// legacyCohortIndexes are indexes created before symptom reports are partitioned by cohorts
+var legacyCohortIndexes = map[string][]string{
+	"symptom_report_versions": {"date_created_at"},
+	"symptom_alerts":          {"symptom_date_unique", "date"},
+}
+
+// SetDefaultCohort sets the cohort which symptom reports uploaded before cohorts are introduced belong to
+func (m *mongodbDataPool) SetDefaultCohort(cohort string) {
+	m.defaultCohort = cohort
+}
+
+// migrateSymptomReportCohorts assigns documents without a cohort to the default cohort,
+// and drops indexes which do not include cohorts
+func migrateSymptomReportCohorts(ctx context.Context, db *mongo.Database, cohort string) error {
+	for _, name := range cohortCollections {
+		collection := db.Collection(name)
+		if err := dropIndexes(ctx, collection, legacyCohortIndexes[name]); err != nil {
+			return err
+		}
+
+		result, err := collection.UpdateMany(ctx,
+			bson.M{"cohort": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"cohort": cohort}})
+		if err != nil {
+			return err
+		}
+
+		log.WithField("prefix", mongoLogPrefix).WithField("collection", name).
+			WithField("count", result.ModifiedCount).Debug("assign documents to the default cohort")
+	}
+
+	return nil
+}
+
+// dropIndexes drops the named indexes of a collection if they exist
+func dropIndexes(ctx context.Context, collection *mongo.Collection, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+
+	cursor, err := collection.Indexes().List(ctx)
+	if err != nil {
+		return err
+	}
+
+	var indexes []struct {
+		Name string `bson:"name"`
+	}
+	if err := cursor.All(ctx, &indexes); err != nil {
+		return err
+	}
+
+	for _, index := range indexes {
+		for _, name := range names {
+			if index.Name != name {
+				continue
+			}
+
+			if _, err := collection.Indexes().DropOne(ctx, index.Name); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CohortMigrationTestSuite struct {
+	suite.Suite
+	connURI     string
+	mongoClient *mongo.Client
+}
+
+func NewCohortMigrationTestSuite(connURI string) *CohortMigrationTestSuite {
+	return &CohortMigrationTestSuite{
+		connURI: connURI,
+	}
+}
+
+func (s *CohortMigrationTestSuite) SetupSuite() {
+	if s.connURI == "" {
+		s.T().Fatal("invalid test suite configuration")
+	}
+
+	opts := options.Client().ApplyURI(s.connURI)
+	mongoClient, err := mongo.NewClient(opts)
+	if nil != err {
+		s.T().Fatalf("create mongo client with error: %s", err)
+	}
+
+	ctx := context.Background()
+	if err = mongoClient.Connect(ctx); nil != err {
+		s.T().Fatalf("connect mongo database with error: %s", err.Error())
+	}
+
+	s.mongoClient = mongoClient
+
+	dbNames, err := mongoClient.ListDatabaseNames(ctx, bson.M{"name": primitive.Regex{Pattern: "^testcase_"}})
+	if err != nil {
+		s.T().Fatalf("list all databases with error: %s", err.Error())
+	}
+
+	for _, name := range dbNames {
+		if err := mongoClient.Database(name).Drop(ctx); err != nil {
+			s.T().Fatalf("drop database with error: %s", err.Error())
+		}
+	}
+
+	// symptom reports and alerts uploaded before cohorts are introduced
+	community := mongoClient.Database(TestDBPrefix + "community")
+	alerts := community.Collection("symptom_alerts")
+	if _, err := alerts.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{"symptom", 1}, {"date", 1}},
+		Options: options.Index().SetUnique(true).SetName("symptom_date_unique"),
+	}); err != nil {
+		s.T().Fatalf("create legacy index with error: %s", err.Error())
+	}
+	if _, err := alerts.InsertOne(ctx, bson.M{"symptom": "Cough", "date": "2020-07-21", "z_score": 3}); err != nil {
+		s.T().Fatalf("insert legacy alert with error: %s", err.Error())
+	}
+	if _, err := community.Collection("symptom_reports").InsertOne(ctx, bson.M{
+		"date": "2020-07-21", "symptoms": bson.A{bson.M{"name": "Cough", "count": 10}}, "checkins_num_past_three_days": 100,
+	}); err != nil {
+		s.T().Fatalf("insert legacy report with error: %s", err.Error())
+	}
+}
+
+func (s *CohortMigrationTestSuite) TestMigrate() {
+	ctx := context.Background()
+	pool := newTestDataPool(s.mongoClient)
+
+	s.NoError(pool.InitCommunityStore())
+	s.NoError(pool.InitCommunityStore())
+
+	report, err := pool.Community().FindLatestDailyReport(ctx, testDefaultCohort)
+	s.NoError(err)
+	s.Equal(100, report.CheckinsNumPastThreeDays)
+
+	alerts, err := pool.Community().GetSymptomAlerts(ctx, testDefaultCohort, "2020-07-21")
+	s.NoError(err)
+	s.Len(alerts, 1)
+
+	// the legacy unique index is dropped, so that cohorts are alerted independently
+	added, err := pool.Community().AddSymptomAlerts(ctx, []SymptomAlert{
+		{Cohort: "testcase_other_cohort", Symptom: "Cough", Date: "2020-07-21", ZScore: 3},
+	})
+	s.NoError(err)
+	s.Len(added, 1)
+}
+
+func TestCohortMigration(t *testing.T) {
+	suite.Run(t, NewCohortMigrationTestSuite("mongodb://127.0.0.1:27017/?compressors=disabled"))
+}
@@ -0,0 +1,66 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// ConsentContributePOIRatings allows the personal data store to contribute POI ratings to the community data store
+	ConsentContributePOIRatings = "contribute_poi_ratings"
+	// ConsentContributeSymptomCheckins allows the personal data store to contribute symptom check-ins to the community data store
+	ConsentContributeSymptomCheckins = "contribute_symptom_checkins"
+)
+
+func init() {
+	PersonalResources.Register(Resource{
+		Name:       "consents",
+		Collection: "consents",
+		Indexes: []mongo.IndexModel{
+			{
+				Keys: bson.D{
+					{"name", 1},
+				},
+				Options: options.Index().SetUnique(true).SetName("name_unique"),
+			},
+		},
+		Export: ExportJSON,
+		Delete: DeleteAccountData,
+	})
+}
+
+// Consent is a permission the account grants to the data store
+type Consent struct {
+	Name      string `bson:"name" json:"name"`
+	Granted   bool   `bson:"granted" json:"granted"`
+	Timestamp int64  `bson:"timestamp" json:"timestamp"`
+}
+
+// SetConsent grants or revokes a consent
+func (m *mongoAccountStore) SetConsent(ctx context.Context, name string, granted bool) error {
+	_, err := m.Resource("consents").UpdateOne(ctx,
+		bson.M{"name": name},
+		bson.M{
+			"$set":         bson.M{"granted": granted, "timestamp": time.Now().UTC().UnixNano() / int64(time.Millisecond)},
+			"$setOnInsert": bson.M{"name": name},
+		},
+		options.Update().SetUpsert(true))
+	return err
+}
+
+// GetConsent returns a consent. A consent which has never been set is not granted.
+func (m *mongoAccountStore) GetConsent(ctx context.Context, name string) (*Consent, error) {
+	var consent Consent
+	if err := m.Resource("consents").FindOne(ctx, bson.M{"name": name}).Decode(&consent); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return &Consent{Name: name}, nil
+		}
+		return nil, err
+	}
+
+	return &consent, nil
+}
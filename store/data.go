@@ -3,17 +3,15 @@ package store
 import (
 	"archive/zip"
 	"context"
-	"encoding/json"
 	"fmt"
 	"io/ioutil"
 
 	"github.com/spf13/afero"
 	"github.com/spf13/viper"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
-var ResourceToExport = []string{"poi_ratings"}
-
 // ExportData prepares an archive file that contains all resources to be exported from personal data store
 func (m *mongoAccountStore) ExportData(ctx context.Context) ([]byte, error) {
 	// prepare temporary archive file
@@ -29,23 +27,8 @@ func (m *mongoAccountStore) ExportData(ctx context.Context) ([]byte, error) {
 
 	archive := zip.NewWriter(zipFile)
 
-	for _, resource := range ResourceToExport {
-		cursor, err := m.Resource(resource).Find(ctx, bson.M{})
-		if err != nil {
-			return nil, err
-		}
-
-		var ratingData []interface{}
-		if err := cursor.All(ctx, &ratingData); err != nil {
-			return nil, err
-		}
-
-		w, err := archive.Create(fmt.Sprintf("pds/%s.json", resource))
-		if err != nil {
-			return nil, err
-		}
-
-		if err := json.NewEncoder(w).Encode(ratingData); err != nil {
+	for _, resource := range PersonalResources.Resources() {
+		if err := exportResource(ctx, archive, "pds", resource, m.Resource(resource.Collection), bson.M{}); err != nil {
 			return nil, err
 		}
 	}
@@ -61,13 +44,33 @@ func (m *mongoAccountStore) ExportData(ctx context.Context) ([]byte, error) {
 	return ioutil.ReadAll(zipFile)
 }
 
+// DeleteData removes data of the account from its personal data store by the delete policy of
+// each resource. Since all documents in a personal data store belong to the account, the collection
+// of a deleted resource is dropped entirely. Collections which are not registered as resources,
+// e.g. those left by earlier versions, have no policy to retain them and are dropped as well.
 func (m *mongoAccountStore) DeleteData(ctx context.Context) error {
+	registered := map[string]bool{}
+	for _, resource := range PersonalResources.Resources() {
+		registered[resource.Collection] = true
+		if resource.Delete != DeleteAccountData {
+			continue
+		}
+
+		if err := m.Resource(resource.Collection).Drop(ctx); err != nil {
+			return err
+		}
+	}
+
 	collectionNames, err := m.db.ListCollectionNames(ctx, bson.D{})
 	if err != nil {
 		return err
 	}
 
 	for _, name := range collectionNames {
+		if registered[name] {
+			continue
+		}
+
 		if err := m.db.Collection(name).Drop(ctx); err != nil {
 			return err
 		}
@@ -94,23 +97,13 @@ func (m *mongoCommunityStore) ExportData(ctx context.Context, accountNumber stri
 
 	archive := zip.NewWriter(zipFile)
 
-	for _, resource := range ResourceToExport {
-		cursor, err := m.Resource(resource).Find(ctx, bson.M{"account_number": accountNumber})
-		if err != nil {
-			return nil, err
-		}
-
-		var ratingData []interface{}
-		if err := cursor.All(ctx, &ratingData); err != nil {
-			return nil, err
-		}
-
-		w, err := archive.Create(fmt.Sprintf("cds/%s.json", resource))
-		if err != nil {
-			return nil, err
+	pseudonym := m.accountPseudonym(accountNumber)
+	for _, resource := range CommunityResources.Resources() {
+		if resource.OwnerKey == "" {
+			continue
 		}
 
-		if err := json.NewEncoder(w).Encode(ratingData); err != nil {
+		if err := exportResource(ctx, archive, "cds", resource, m.Resource(resource.Collection), bson.M{resource.OwnerKey: pseudonym}); err != nil {
 			return nil, err
 		}
 	}
@@ -125,3 +118,42 @@ func (m *mongoCommunityStore) ExportData(ctx context.Context, accountNumber stri
 
 	return ioutil.ReadAll(zipFile)
 }
+
+// DeleteData removes documents of an account from resources in the community data store
+func (m *mongoCommunityStore) DeleteData(ctx context.Context, accountNumber string) error {
+	if accountNumber == "" {
+		return fmt.Errorf("empty account number error")
+	}
+
+	pseudonym := m.accountPseudonym(accountNumber)
+	for _, resource := range CommunityResources.Resources() {
+		if resource.Delete != DeleteAccountData || resource.OwnerKey == "" {
+			continue
+		}
+
+		if _, err := m.Resource(resource.Collection).DeleteMany(ctx, bson.M{resource.OwnerKey: pseudonym}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportResource writes documents matched by the filter into a file of the archive
+func exportResource(ctx context.Context, archive *zip.Writer, dir string, resource Resource, collection *mongo.Collection, filter bson.M) error {
+	if resource.Export == nil {
+		return nil
+	}
+
+	cursor, err := collection.Find(ctx, filter)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	w, err := archive.Create(fmt.Sprintf("%s/%s.json", dir, resource.Name))
+	if err != nil {
+		return err
+	}
+
+	return resource.Export(ctx, cursor, w)
+}
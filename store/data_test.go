@@ -34,19 +34,24 @@ var (
 	}
 
 	testDataExportCommunityRating1 = map[string]interface{}{
-		"id":             testDataExport2ID,
-		"account_number": testDataExportAccount,
-		"ratings":        map[string]float64{"a": 2},
+		"id":                testDataExport2ID,
+		"account_pseudonym": pseudonymize(testPseudonymKey, testDataExportAccount),
+		"ratings":           map[string]float64{"a": 2},
 	}
 	testDataExportCommunityRating2 = map[string]interface{}{
-		"id":             testDataExport2ID,
-		"account_number": testDataExportAccount,
-		"ratings":        map[string]float64{"b": 3},
+		"id":                testDataExport2ID,
+		"account_pseudonym": pseudonymize(testPseudonymKey, testDataExportAccount),
+		"ratings":           map[string]float64{"b": 3},
 	}
 	testDataExportCommunityRating3 = map[string]interface{}{
-		"id":             testDataExport3ID,
-		"account_number": testDataExportAccount,
-		"ratings":        map[string]float64{"b": 4},
+		"id":                testDataExport3ID,
+		"account_pseudonym": pseudonymize(testPseudonymKey, testDataExportAccount),
+		"ratings":           map[string]float64{"b": 4},
+	}
+	testDataDeleteCommunityRating = map[string]interface{}{
+		"id":                testDataExport3ID,
+		"account_pseudonym": pseudonymize(testPseudonymKey, testDataDeleteAccount),
+		"ratings":           map[string]float64{"b": 5},
 	}
 )
 
@@ -111,6 +116,7 @@ func (s *DataManagementTestSuite) LoadFixtures() error {
 		testDataExportCommunityRating1,
 		testDataExportCommunityRating2,
 		testDataExportCommunityRating3,
+		testDataDeleteCommunityRating,
 	}); err != nil {
 		return err
 	}
@@ -128,7 +134,7 @@ func (s *DataManagementTestSuite) LoadFixtures() error {
 // TestPDSExport checks archive file extraction and files for a PDS exporting file
 func (s *DataManagementTestSuite) TestPDSExport() {
 	ctx := context.Background()
-	data, err := NewMongodbDataPool(s.mongoClient, TestDBPrefix).Account(testDataExportAccount).ExportData(ctx)
+	data, err := newTestDataPool(s.mongoClient).Account(testDataExportAccount).ExportData(ctx)
 	s.NoError(err)
 	s.NotZero(len(data))
 
@@ -146,7 +152,9 @@ func (s *DataManagementTestSuite) TestPDSExport() {
 		rating := []interface{}{}
 
 		s.NoError(json.NewDecoder(r).Decode(&rating))
-		s.Len(rating, 2)
+		if file.Name == "pds/poi_ratings.json" {
+			s.Len(rating, 2)
+		}
 	}
 
 	s.Contains(fileNames, "pds/poi_ratings.json")
@@ -155,7 +163,7 @@ func (s *DataManagementTestSuite) TestPDSExport() {
 // TestCDSExport checks archive file extraction and files for a CDS exporting file
 func (s *DataManagementTestSuite) TestCDSExport() {
 	ctx := context.Background()
-	data, err := NewMongodbDataPool(s.mongoClient, TestDBPrefix).Community().ExportData(ctx, testDataExportAccount)
+	data, err := newTestDataPool(s.mongoClient).Community().ExportData(ctx, testDataExportAccount)
 	s.NoError(err)
 	s.NotZero(len(data))
 
@@ -173,7 +181,9 @@ func (s *DataManagementTestSuite) TestCDSExport() {
 		rating := []interface{}{}
 
 		s.NoError(json.NewDecoder(r).Decode(&rating))
-		s.Len(rating, 3)
+		if file.Name == "cds/poi_ratings.json" {
+			s.Len(rating, 3)
+		}
 	}
 
 	s.Contains(fileNames, "cds/poi_ratings.json")
@@ -182,7 +192,7 @@ func (s *DataManagementTestSuite) TestCDSExport() {
 // TestPDSDataDelete validates whether an account database disappears after it is removed
 func (s *DataManagementTestSuite) TestPDSDataDelete() {
 	ctx := context.Background()
-	err := NewMongodbDataPool(s.mongoClient, TestDBPrefix).Account(testDataDeleteAccount).DeleteData(ctx)
+	err := newTestDataPool(s.mongoClient).Account(testDataDeleteAccount).DeleteData(ctx)
 	s.NoError(err)
 
 	dbNames, err := s.mongoClient.ListDatabaseNames(ctx, bson.M{"name": primitive.Regex{Pattern: fmt.Sprintf("^%s", TestDBPrefix)}})
@@ -195,6 +205,21 @@ func (s *DataManagementTestSuite) TestPDSDataDelete() {
 	}
 }
 
+// TestCDSDataDelete validates whether the ratings of an account are removed from the community store
+func (s *DataManagementTestSuite) TestCDSDataDelete() {
+	ctx := context.Background()
+	err := newTestDataPool(s.mongoClient).Community().DeleteData(ctx, testDataDeleteAccount)
+	s.NoError(err)
+
+	count, err := s.mongoClient.Database(TestDBPrefix+"community").Collection("poi_ratings").CountDocuments(ctx, bson.M{"account_pseudonym": pseudonymize(testPseudonymKey, testDataDeleteAccount)})
+	s.NoError(err)
+	s.Equal(int64(0), count)
+
+	count, err = s.mongoClient.Database(TestDBPrefix+"community").Collection("poi_ratings").CountDocuments(ctx, bson.M{"account_pseudonym": pseudonymize(testPseudonymKey, testDataExportAccount)})
+	s.NoError(err)
+	s.Equal(int64(3), count)
+}
+
 func TestDataManagement(t *testing.T) {
 	suite.Run(t, NewDataManagementTestSuite("mongodb://127.0.0.1:27017/?compressors=disabled"))
 }
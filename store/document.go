@@ -0,0 +1,200 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func init() {
+	PersonalResources.Register(Resource{
+		Name:       "documents",
+		Collection: "documents",
+		Indexes: []mongo.IndexModel{
+			{
+				Keys: bson.D{
+					{"collection", 1},
+					{"id", 1},
+				},
+				Options: options.Index().SetUnique(true).SetName("collection_id_unique"),
+			},
+		},
+		Export: exportDocuments,
+		Delete: DeleteAccountData,
+	})
+}
+
+// Document is a JSON document kept under a named collection of a personal data store
+type Document struct {
+	Collection string   `bson:"collection" json:"collection"`
+	ID         string   `bson:"id" json:"id"`
+	Body       bson.Raw `bson:"body" json:"-"`
+	Timestamp  int64    `bson:"timestamp" json:"timestamp"`
+}
+
+// MarshalJSON encodes the document with its body converted back to plain JSON
+func (d Document) MarshalJSON() ([]byte, error) {
+	body := []byte("{}")
+	if len(d.Body) > 0 {
+		b, err := bson.MarshalExtJSON(d.Body, false, false)
+		if err != nil {
+			return nil, err
+		}
+		body = b
+	}
+
+	type document Document
+	return json.Marshal(struct {
+		document
+		Body json.RawMessage `json:"body"`
+	}{document(d), body})
+}
+
+// ValidateDocument checks whether a body can be stored as a document
+func ValidateDocument(body []byte) error {
+	_, err := decodeJSONObject(body)
+	return err
+}
+
+// decodeJSONObject decodes a plain JSON object. Integers are kept as integers, so they are
+// not converted back as floating numbers. Keys which start with `$` or contain `.` are rejected
+// at any level, since they are operators or paths to MongoDB rather than plain field names.
+func decodeJSONObject(body []byte) (map[string]interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var object map[string]interface{}
+	if err := decoder.Decode(&object); err != nil {
+		return nil, err
+	}
+	if object == nil {
+		return nil, fmt.Errorf("document must be a JSON object")
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("invalid trailing data after the document")
+	}
+	if err := validateKeys(object); err != nil {
+		return nil, err
+	}
+
+	return convertJSONNumbers(object).(map[string]interface{}), nil
+}
+
+// validateKeys rejects keys of nested objects which can not be stored as plain field names
+func validateKeys(v interface{}) error {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			if strings.HasPrefix(k, "$") || strings.Contains(k, ".") {
+				return fmt.Errorf("invalid key %q: keys must not start with $ or contain .", k)
+			}
+			if err := validateKeys(e); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, e := range v {
+			if err := validateKeys(e); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// convertJSONNumbers replaces json.Number values with int64 or float64 values
+func convertJSONNumbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = convertJSONNumbers(e)
+		}
+	case []interface{}:
+		for i, e := range v {
+			v[i] = convertJSONNumbers(e)
+		}
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	}
+	return v
+}
+
+// PutDocument creates or replaces a document. The body must be a plain JSON object whose keys
+// do not start with `$` or contain `.`, so extended JSON types, e.g. `{"$date": ...}`, are not
+// supported.
+func (m *mongoAccountStore) PutDocument(ctx context.Context, collection, id string, body []byte) error {
+	doc, err := decodeJSONObject(body)
+	if err != nil {
+		return err
+	}
+
+	_, err = m.Resource("documents").UpdateOne(ctx,
+		bson.M{"collection": collection, "id": id},
+		bson.M{
+			"$set":         bson.M{"body": doc, "timestamp": time.Now().UTC().UnixNano() / int64(time.Millisecond)},
+			"$setOnInsert": bson.M{"collection": collection, "id": id},
+		},
+		options.Update().SetUpsert(true))
+	return err
+}
+
+// GetDocument returns a document. It returns mongo.ErrNoDocuments if the document is not found.
+func (m *mongoAccountStore) GetDocument(ctx context.Context, collection, id string) (*Document, error) {
+	var doc Document
+	if err := m.Resource("documents").FindOne(ctx, bson.M{"collection": collection, "id": id}).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	return &doc, nil
+}
+
+// ListDocuments returns all documents of a collection ordered by id
+func (m *mongoAccountStore) ListDocuments(ctx context.Context, collection string) ([]Document, error) {
+	cursor, err := m.Resource("documents").Find(ctx, bson.M{"collection": collection}, options.Find().SetSort(bson.D{{"id", 1}}))
+	if err != nil {
+		return nil, err
+	}
+
+	docs := []Document{}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	return docs, nil
+}
+
+// DeleteDocument removes a document. It returns mongo.ErrNoDocuments if the document is not found.
+func (m *mongoAccountStore) DeleteDocument(ctx context.Context, collection, id string) error {
+	result, err := m.Resource("documents").DeleteOne(ctx, bson.M{"collection": collection, "id": id})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+// exportDocuments encodes documents with their bodies as plain JSON
+func exportDocuments(ctx context.Context, cursor *mongo.Cursor, w io.Writer) error {
+	docs := []Document{}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return err
+	}
+
+	return json.NewEncoder(w).Encode(docs)
+}
@@ -0,0 +1,126 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	testDocumentAccount = "document-account"
+)
+
+type DocumentTestSuite struct {
+	suite.Suite
+	connURI     string
+	mongoClient *mongo.Client
+}
+
+func NewDocumentTestSuite(connURI string) *DocumentTestSuite {
+	return &DocumentTestSuite{
+		connURI: connURI,
+	}
+}
+
+func (s *DocumentTestSuite) SetupSuite() {
+	if s.connURI == "" {
+		s.T().Fatal("invalid test suite configuration")
+	}
+
+	opts := options.Client().ApplyURI(s.connURI)
+	mongoClient, err := mongo.NewClient(opts)
+	if nil != err {
+		s.T().Fatalf("create mongo client with error: %s", err)
+	}
+
+	ctx := context.Background()
+	if err = mongoClient.Connect(ctx); nil != err {
+		s.T().Fatalf("connect mongo database with error: %s", err.Error())
+	}
+
+	s.mongoClient = mongoClient
+
+	dbNames, err := mongoClient.ListDatabaseNames(ctx, bson.M{"name": primitive.Regex{Pattern: "^testcase_"}})
+	if err != nil {
+		s.T().Fatalf("list all databases with error: %s", err.Error())
+	}
+
+	for _, name := range dbNames {
+		if err := mongoClient.Database(name).Drop(ctx); err != nil {
+			s.T().Fatalf("drop database with error: %s", err.Error())
+		}
+	}
+}
+
+func (s *DocumentTestSuite) TestDocumentLifecycle() {
+	ctx := context.Background()
+	store := newTestDataPool(s.mongoClient).Account(testDocumentAccount)
+
+	s.NoError(store.PutDocument(ctx, "notes", "n1", []byte(`{"text":"hello","tags":["a","b"]}`)))
+	s.NoError(store.PutDocument(ctx, "notes", "n2", []byte(`{"text":"world"}`)))
+	s.NoError(store.PutDocument(ctx, "notes", "n1", []byte(`{"text":"hello again"}`)))
+	s.NoError(store.PutDocument(ctx, "settings", "n1", []byte(`{"dark":true}`)))
+
+	doc, err := store.GetDocument(ctx, "notes", "n1")
+	s.NoError(err)
+	s.Equal("hello again", doc.Body.Lookup("text").StringValue())
+
+	docs, err := store.ListDocuments(ctx, "notes")
+	s.NoError(err)
+	s.Len(docs, 2)
+	s.Equal("n1", docs[0].ID)
+	s.Equal("n2", docs[1].ID)
+
+	s.NoError(store.DeleteDocument(ctx, "notes", "n1"))
+	s.Equal(mongo.ErrNoDocuments, store.DeleteDocument(ctx, "notes", "n1"))
+
+	_, err = store.GetDocument(ctx, "notes", "n1")
+	s.Equal(mongo.ErrNoDocuments, err)
+
+	doc, err = store.GetDocument(ctx, "settings", "n1")
+	s.NoError(err)
+	s.True(doc.Body.Lookup("dark").Boolean())
+}
+
+func TestDocumentMarshalJSON(t *testing.T) {
+	var body bson.Raw
+	assert.NoError(t, bson.UnmarshalExtJSON([]byte(`{"text":"hello","count":2,"nested":{"ok":true}}`), false, &body))
+
+	data, err := json.Marshal(Document{Collection: "notes", ID: "n1", Body: body, Timestamp: 1})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"collection":"notes","id":"n1","timestamp":1,"body":{"text":"hello","count":2,"nested":{"ok":true}}}`, string(data))
+}
+
+func TestDecodeJSONObject(t *testing.T) {
+	object, err := decodeJSONObject([]byte(`{"count":2,"ratio":0.5,"at":{"date":"2020-07-21T00:00:00Z"},"list":[1,{"n":3}]}`))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), object["count"])
+	assert.Equal(t, 0.5, object["ratio"])
+	assert.Equal(t, map[string]interface{}{"date": "2020-07-21T00:00:00Z"}, object["at"])
+	assert.Equal(t, []interface{}{int64(1), map[string]interface{}{"n": int64(3)}}, object["list"])
+
+	var body bson.Raw
+	body, err = bson.Marshal(object)
+	assert.NoError(t, err)
+	data, err := json.Marshal(Document{Body: body})
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"count":2`)
+
+	invalids := []string{`[1]`, `null`, `{"a":1} {}`, `{"a":`,
+		`{"$set":1}`, `{"a.b":1}`, `{"at":{"$date":"2020-07-21T00:00:00Z"}}`, `{"list":[{"$gt":1}]}`}
+	for _, invalid := range invalids {
+		_, err := decodeJSONObject([]byte(invalid))
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestDocument(t *testing.T) {
+	suite.Run(t, NewDocumentTestSuite("mongodb://127.0.0.1:27017/?compressors=disabled"))
+}
@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 	"fmt"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -20,28 +21,67 @@ type DataStorePool interface {
 
 	// Community will return a community data store.
 	Community() CommunityDataStore
+
+	// Outbox will return the store of messages to be delivered to other services.
+	Outbox() OutboxStore
 }
 
 type PersonalDataStore interface {
 	SetPOIRating(ctx context.Context, poiID string, ratings map[string]float64) error
 	GetPOIRating(ctx context.Context, poiID string) (map[string]float64, error)
+	GetPOIRatingHistory(ctx context.Context, poiID string) ([]POIRatingRecord, error)
+	PutDocument(ctx context.Context, collection, id string, body []byte) error
+	GetDocument(ctx context.Context, collection, id string) (*Document, error)
+	ListDocuments(ctx context.Context, collection string) ([]Document, error)
+	DeleteDocument(ctx context.Context, collection, id string) error
+	SetConsent(ctx context.Context, name string, granted bool) error
+	GetConsent(ctx context.Context, name string) (*Consent, error)
+	SetSymptomCheckin(ctx context.Context, checkin SymptomCheckin) error
+	GetSymptomCheckins(ctx context.Context, since string) ([]SymptomCheckin, error)
 	ExportData(ctx context.Context) ([]byte, error)
 	DeleteData(ctx context.Context) error
 }
 
 type CommunityDataStore interface {
 	SetPOIRating(ctx context.Context, accountNumber, poiID string, ratings map[string]float64) error
-	GetPOISummarizedRatings(ctx context.Context, poiIDs []string) (map[string]POISummarizedRating, error)
+	GetPOISummarizedRatings(ctx context.Context, poiIDs []string, opts ...POISummaryOption) (map[string]POISummarizedRating, error)
+	AddPOI(ctx context.Context, poi POI) error
+	GetNearbyPOIs(ctx context.Context, lat, lng float64, radius int) ([]POI, error)
+	GetTopPOIs(ctx context.Context, option POIRankingOption) ([]POIRanking, error)
+	GetTrendingPOIs(ctx context.Context, period time.Duration, option POIRankingOption) ([]POIRanking, error)
+	SetSymptomCheckin(ctx context.Context, accountNumber string, checkin SymptomCheckin) error
+	AggregateSymptomCheckins(ctx context.Context, start, end string) ([]SymptomDailyReport, error)
 	AddSymptomDailyReports(ctx context.Context, reports []SymptomDailyReport) error
-	FindLatestDailyReport(ctx context.Context) (*SymptomDailyReport, error)
-	GetSymptomReportItems(ctx context.Context, end string, limit int64) (map[string][]Bucket, error)
+	AddSymptomReportBatch(ctx context.Context, batch *SymptomReportBatch, reports []SymptomDailyReport) error
+	ListSymptomReportBatches(ctx context.Context, cohort string, limit int64) ([]SymptomReportBatch, error)
+	RollbackSymptomReportBatch(ctx context.Context, batchID, rolledBackBy string) (*SymptomReportBatch, error)
+	GetSymptomDailyReports(ctx context.Context, cohort, start, end string) ([]SymptomDailyReport, error)
+	GetSymptomTimeseries(ctx context.Context, cohort, start, end string) (map[string][]Bucket, error)
+	FindLatestDailyReport(ctx context.Context, cohort string) (*SymptomDailyReport, error)
+	AddSymptomAlerts(ctx context.Context, alerts []SymptomAlert) ([]SymptomAlert, error)
+	GetSymptomAlerts(ctx context.Context, cohort, since string) ([]SymptomAlert, error)
+	GetSymptomReportItems(ctx context.Context, cohort, start, end string) (map[string][]Bucket, *SymptomReportCoverage, error)
+	SpendPrivacyBudget(ctx context.Context, dataset string, epsilon, limit float64, period time.Duration) error
+	GetPrivacyReleases(ctx context.Context, dataset string, keys []string) (map[string]PrivacyRelease, error)
+	SetPrivacyRelease(ctx context.Context, release PrivacyRelease) error
+	SetNotificationPreference(ctx context.Context, accountNumber string, poiScoreChanges bool) (*NotificationPreference, error)
+	GetNotificationPreference(ctx context.Context, accountNumber string) (*NotificationPreference, error)
+	GetPOIScoreSubscribers(ctx context.Context, poiID string) ([]string, error)
+	ClaimNotificationQuota(ctx context.Context, externalUserIDs []string, interval time.Duration) (*NotificationQuota, error)
+	ReleaseNotificationQuota(ctx context.Context, quota NotificationQuota) error
+	GetPOIScoreSnapshot(ctx context.Context, poiID string) (*POIScoreSnapshot, error)
+	SetPOIScoreSnapshot(ctx context.Context, snapshot POIScoreSnapshot) error
 	ExportData(ctx context.Context, accountNumber string) ([]byte, error)
+	DeleteData(ctx context.Context, accountNumber string) error
 }
 
 // mongodbDataPool is an implementation of DataStorePool.
 type mongodbDataPool struct {
-	client   *mongo.Client
-	dbPrefix string
+	client       *mongo.Client
+	dbPrefix     string
+	pseudonymKey []byte
+	// defaultCohort is only used to migrate the community store
+	defaultCohort string
 }
 
 // NewMongodbDataPool returns a mongodbDataPool instance
@@ -71,7 +111,8 @@ func (m mongodbDataPool) Account(accountNumber string) PersonalDataStore {
 func (m mongodbDataPool) Community() CommunityDataStore {
 	dbName := fmt.Sprintf("%scommunity", m.dbPrefix)
 	return &mongoCommunityStore{
-		db: m.client.Database(dbName),
+		db:           m.client.Database(dbName),
+		pseudonymKey: m.pseudonymKey,
 	}
 }
 
@@ -98,7 +139,10 @@ func (m mongoAccountStore) Resource(name string) *mongo.Collection {
 }
 
 type mongoCommunityStore struct {
-	db *mongo.Database
+	db           *mongo.Database
+	pseudonymKey []byte
+	// defaultCohort is only used to migrate the community store
+	defaultCohort string
 }
 
 // Resource returns the collection of the given resource from the database
@@ -0,0 +1,183 @@
+package store
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func init() {
+	CommunityResources.Register(Resource{
+		Name:       "notification_preferences",
+		Collection: "notification_preferences",
+		Indexes: []mongo.IndexModel{
+			{
+				Keys: bson.D{
+					{"account_pseudonym", 1},
+				},
+				Options: options.Index().SetUnique(true).SetName("account_pseudonym_unique"),
+			},
+			{
+				Keys: bson.D{
+					{"notification_id", 1},
+				},
+				Options: options.Index().SetUnique(true).SetSparse(true).SetName("notification_id_unique"),
+			},
+		},
+		OwnerKey: "account_pseudonym",
+		Export:   ExportJSON,
+		Delete:   DeleteAccountData,
+	})
+}
+
+// NotificationPreference is the notifications an account opts in to. The account is notified
+// by a random notification id, which the app registers as the external user id to the
+// notification provider. The pseudonym of the account is never shared with the provider.
+type NotificationPreference struct {
+	AccountPseudonym string `bson:"account_pseudonym" json:"-"`
+	ExternalUserID   string `bson:"notification_id,omitempty" json:"external_user_id,omitempty"`
+	// POIScoreChanges notifies the account when the community score of a POI it rated changes
+	POIScoreChanges bool  `bson:"poi_score_changes" json:"poi_score_changes"`
+	Timestamp       int64 `bson:"timestamp" json:"timestamp"`
+	// LastNotifiedAt is the time in milliseconds the account is last notified
+	LastNotifiedAt int64 `bson:"last_notified_at,omitempty" json:"last_notified_at,omitempty"`
+}
+
+// newNotificationID returns a random id which identifies an account to the notification provider
+func newNotificationID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// SetNotificationPreference opts an account in or out of notifications of POI score changes.
+// The notification id of the account is issued when the preference is first set.
+func (m *mongoCommunityStore) SetNotificationPreference(ctx context.Context, accountNumber string, poiScoreChanges bool) (*NotificationPreference, error) {
+	pseudonym := m.accountPseudonym(accountNumber)
+	notificationID, err := newNotificationID()
+	if err != nil {
+		return nil, err
+	}
+
+	var preference NotificationPreference
+	err = m.Resource("notification_preferences").FindOneAndUpdate(ctx,
+		bson.M{"account_pseudonym": pseudonym},
+		bson.M{
+			"$set":         bson.M{"poi_score_changes": poiScoreChanges, "timestamp": nowInMillisecond()},
+			"$setOnInsert": bson.M{"account_pseudonym": pseudonym, "notification_id": notificationID},
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)).Decode(&preference)
+	if err != nil {
+		return nil, err
+	}
+
+	return &preference, nil
+}
+
+// GetNotificationPreference returns the notification preference of an account. An account
+// which has never set its preference is not opted in, and has no notification id.
+func (m *mongoCommunityStore) GetNotificationPreference(ctx context.Context, accountNumber string) (*NotificationPreference, error) {
+	pseudonym := m.accountPseudonym(accountNumber)
+
+	var preference NotificationPreference
+	if err := m.Resource("notification_preferences").FindOne(ctx, bson.M{"account_pseudonym": pseudonym}).Decode(&preference); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return &NotificationPreference{AccountPseudonym: pseudonym}, nil
+		}
+		return nil, err
+	}
+
+	return &preference, nil
+}
+
+// GetPOIScoreSubscribers returns the notification ids of accounts which rated a POI and opt
+// in to notifications of its score changes
+func (m *mongoCommunityStore) GetPOIScoreSubscribers(ctx context.Context, poiID string) ([]string, error) {
+	raters, err := m.Resource("poi_ratings").Distinct(ctx, "account_pseudonym", bson.M{"id": poiID})
+	if err != nil {
+		return nil, err
+	}
+	if len(raters) == 0 {
+		return []string{}, nil
+	}
+
+	cursor, err := m.Resource("notification_preferences").Find(ctx,
+		bson.M{"account_pseudonym": bson.M{"$in": raters}, "poi_score_changes": true},
+		options.Find().SetProjection(bson.M{"notification_id": 1}).SetSort(bson.M{"notification_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+
+	var preferences []NotificationPreference
+	if err := cursor.All(ctx, &preferences); err != nil {
+		return nil, err
+	}
+
+	subscribers := make([]string, 0, len(preferences))
+	for _, p := range preferences {
+		subscribers = append(subscribers, p.ExternalUserID)
+	}
+	return subscribers, nil
+}
+
+// NotificationQuota is the users allowed to be notified by a claim, and the time they are claimed
+type NotificationQuota struct {
+	ExternalUserIDs []string
+	ClaimedAt       int64
+}
+
+// ClaimNotificationQuota rate limits notifications of users. A user who opts in is allowed to
+// be notified if it is not notified within the interval, and its notified time is updated.
+// The quota of the users allowed is returned.
+func (m *mongoCommunityStore) ClaimNotificationQuota(ctx context.Context, externalUserIDs []string, interval time.Duration) (*NotificationQuota, error) {
+	now := nowInMillisecond()
+	quota := NotificationQuota{
+		ExternalUserIDs: make([]string, 0, len(externalUserIDs)),
+		ClaimedAt:       now,
+	}
+	for _, id := range externalUserIDs {
+		result, err := m.Resource("notification_preferences").UpdateOne(ctx,
+			bson.M{
+				"notification_id":   id,
+				"poi_score_changes": true,
+				"$or": bson.A{
+					bson.M{"last_notified_at": bson.M{"$exists": false}},
+					bson.M{"last_notified_at": bson.M{"$lte": now - interval.Milliseconds()}},
+				},
+			},
+			bson.M{"$set": bson.M{"last_notified_at": now}})
+		if err != nil {
+			return nil, err
+		}
+
+		if result.MatchedCount > 0 {
+			quota.ExternalUserIDs = append(quota.ExternalUserIDs, id)
+		}
+	}
+
+	return &quota, nil
+}
+
+// ReleaseNotificationQuota gives back a claimed quota whose notifications are not sent. The users
+// were allowed to be notified before the claim, so they are allowed again. Users claimed again
+// since are kept.
+func (m *mongoCommunityStore) ReleaseNotificationQuota(ctx context.Context, quota NotificationQuota) error {
+	if len(quota.ExternalUserIDs) == 0 {
+		return nil
+	}
+
+	_, err := m.Resource("notification_preferences").UpdateMany(ctx,
+		bson.M{
+			"notification_id":  bson.M{"$in": quota.ExternalUserIDs},
+			"last_notified_at": quota.ClaimedAt,
+		},
+		bson.M{"$unset": bson.M{"last_notified_at": ""}})
+	return err
+}
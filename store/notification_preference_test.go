@@ -0,0 +1,177 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type NotificationPreferenceTestSuite struct {
+	suite.Suite
+	connURI     string
+	mongoClient *mongo.Client
+}
+
+func NewNotificationPreferenceTestSuite(connURI string) *NotificationPreferenceTestSuite {
+	return &NotificationPreferenceTestSuite{
+		connURI: connURI,
+	}
+}
+
+func (s *NotificationPreferenceTestSuite) SetupSuite() {
+	if s.connURI == "" {
+		s.T().Fatal("invalid test suite configuration")
+	}
+
+	opts := options.Client().ApplyURI(s.connURI)
+	mongoClient, err := mongo.NewClient(opts)
+	if nil != err {
+		s.T().Fatalf("create mongo client with error: %s", err)
+	}
+
+	ctx := context.Background()
+	if err = mongoClient.Connect(ctx); nil != err {
+		s.T().Fatalf("connect mongo database with error: %s", err.Error())
+	}
+
+	s.mongoClient = mongoClient
+
+	dbNames, err := mongoClient.ListDatabaseNames(ctx, bson.M{"name": primitive.Regex{Pattern: "^testcase_"}})
+	if err != nil {
+		s.T().Fatalf("list all databases with error: %s", err.Error())
+	}
+
+	for _, name := range dbNames {
+		if err := mongoClient.Database(name).Drop(ctx); err != nil {
+			s.T().Fatalf("drop database with error: %s", err.Error())
+		}
+	}
+
+	if err := newTestDataPool(s.mongoClient).InitCommunityStore(); err != nil {
+		s.T().Fatalf("init community store with error: %s", err.Error())
+	}
+}
+
+func (s *NotificationPreferenceTestSuite) TestNotificationPreference() {
+	ctx := context.Background()
+	store := newTestDataPool(s.mongoClient).Community()
+
+	preference, err := store.GetNotificationPreference(ctx, "testcase_account1")
+	s.NoError(err)
+	s.False(preference.POIScoreChanges)
+	s.Empty(preference.ExternalUserID)
+
+	updated, err := store.SetNotificationPreference(ctx, "testcase_account1", true)
+	s.NoError(err)
+	s.True(updated.POIScoreChanges)
+	s.Len(updated.ExternalUserID, 32)
+	// the notification id is not derived from the account
+	s.NotEqual(updated.AccountPseudonym, updated.ExternalUserID)
+
+	preference, err = store.GetNotificationPreference(ctx, "testcase_account1")
+	s.NoError(err)
+	s.True(preference.POIScoreChanges)
+	s.Equal(updated.ExternalUserID, preference.ExternalUserID)
+
+	// the notification id is kept when the preference changes
+	updated, err = store.SetNotificationPreference(ctx, "testcase_account1", false)
+	s.NoError(err)
+	s.Equal(preference.ExternalUserID, updated.ExternalUserID)
+
+	other, err := store.SetNotificationPreference(ctx, "testcase_account7", true)
+	s.NoError(err)
+	s.NotEqual(preference.ExternalUserID, other.ExternalUserID)
+}
+
+func (s *NotificationPreferenceTestSuite) TestPOIScoreSubscribers() {
+	ctx := context.Background()
+	store := newTestDataPool(s.mongoClient).Community()
+	poiID := "testcase_poi_subscribers"
+
+	for _, account := range []string{"testcase_account2", "testcase_account3", "testcase_account4"} {
+		s.NoError(store.SetPOIRating(ctx, account, poiID, map[string]float64{"mask": 3}))
+	}
+	optedIn, err := store.SetNotificationPreference(ctx, "testcase_account2", true)
+	s.NoError(err)
+	_, err = store.SetNotificationPreference(ctx, "testcase_account3", false)
+	s.NoError(err)
+	// accounts which do not rate the POI are not notified
+	_, err = store.SetNotificationPreference(ctx, "testcase_account5", true)
+	s.NoError(err)
+
+	subscribers, err := store.GetPOIScoreSubscribers(ctx, poiID)
+	s.NoError(err)
+	s.Equal([]string{optedIn.ExternalUserID}, subscribers)
+
+	subscribers, err = store.GetPOIScoreSubscribers(ctx, "testcase_poi_unknown")
+	s.NoError(err)
+	s.Empty(subscribers)
+}
+
+func (s *NotificationPreferenceTestSuite) TestClaimNotificationQuota() {
+	ctx := context.Background()
+	store := newTestDataPool(s.mongoClient).Community()
+
+	preference, err := store.SetNotificationPreference(ctx, "testcase_account6", true)
+	s.NoError(err)
+
+	quota, err := store.ClaimNotificationQuota(ctx, []string{preference.ExternalUserID, "testcase_unknown"}, time.Hour)
+	s.NoError(err)
+	s.Equal([]string{preference.ExternalUserID}, quota.ExternalUserIDs)
+
+	// the user is notified within the interval
+	claimed, err := store.ClaimNotificationQuota(ctx, []string{preference.ExternalUserID}, time.Hour)
+	s.NoError(err)
+	s.Empty(claimed.ExternalUserIDs)
+
+	// a released quota can be claimed again
+	s.NoError(store.ReleaseNotificationQuota(ctx, *quota))
+	claimed, err = store.ClaimNotificationQuota(ctx, []string{preference.ExternalUserID}, time.Hour)
+	s.NoError(err)
+	s.Len(claimed.ExternalUserIDs, 1)
+
+	// a stale quota does not release the later claim
+	s.NoError(store.ReleaseNotificationQuota(ctx, NotificationQuota{ExternalUserIDs: claimed.ExternalUserIDs, ClaimedAt: claimed.ClaimedAt - 1}))
+	claimed, err = store.ClaimNotificationQuota(ctx, []string{preference.ExternalUserID}, time.Hour)
+	s.NoError(err)
+	s.Empty(claimed.ExternalUserIDs)
+
+	claimed, err = store.ClaimNotificationQuota(ctx, []string{preference.ExternalUserID}, 0)
+	s.NoError(err)
+	s.Len(claimed.ExternalUserIDs, 1)
+
+	// users who opt out are not notified
+	_, err = store.SetNotificationPreference(ctx, "testcase_account6", false)
+	s.NoError(err)
+	claimed, err = store.ClaimNotificationQuota(ctx, []string{preference.ExternalUserID}, 0)
+	s.NoError(err)
+	s.Empty(claimed.ExternalUserIDs)
+}
+
+func (s *NotificationPreferenceTestSuite) TestPOIScoreSnapshot() {
+	ctx := context.Background()
+	store := newTestDataPool(s.mongoClient).Community()
+
+	snapshot, err := store.GetPOIScoreSnapshot(ctx, "testcase_poi_snapshot")
+	s.NoError(err)
+	s.Nil(snapshot)
+
+	s.NoError(store.SetPOIScoreSnapshot(ctx, POIScoreSnapshot{ID: "testcase_poi_snapshot", Score: 3, RatingCount: 5}))
+	s.NoError(store.SetPOIScoreSnapshot(ctx, POIScoreSnapshot{ID: "testcase_poi_snapshot", Score: 3.5, RatingCount: 6}))
+
+	snapshot, err = store.GetPOIScoreSnapshot(ctx, "testcase_poi_snapshot")
+	s.NoError(err)
+	s.Equal(3.5, snapshot.Score)
+	s.Equal(int64(6), snapshot.RatingCount)
+	s.NotZero(snapshot.Timestamp)
+}
+
+func TestNotificationPreference(t *testing.T) {
+	suite.Run(t, NewNotificationPreferenceTestSuite("mongodb://127.0.0.1:27017/?compressors=disabled"))
+}
@@ -0,0 +1,230 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	OutboxStatusPending   = "pending"
+	OutboxStatusDelivered = "delivered"
+	OutboxStatusFailed    = "failed"
+)
+
+// OutboxStore keeps messages to be delivered to other services until they are delivered
+type OutboxStore interface {
+	// Enqueue adds a message. A pending message with the same dedup key is replaced.
+	Enqueue(ctx context.Context, msg OutboxMessage) error
+	// Claim takes the next due message of a kind and hides it from other claims for the lease.
+	// It returns mongo.ErrNoDocuments if there is no due message.
+	Claim(ctx context.Context, kind string, lease time.Duration) (*OutboxMessage, error)
+	// MarkDelivered marks a claimed message as delivered, unless it has been replaced since claimed.
+	MarkDelivered(ctx context.Context, msg *OutboxMessage) error
+	// MarkRetry schedules a claimed message to be delivered again.
+	MarkRetry(ctx context.Context, msg *OutboxMessage, retryAt time.Time, reason string) error
+	// MarkFailed gives up a claimed message.
+	MarkFailed(ctx context.Context, msg *OutboxMessage, reason string) error
+	// List returns messages of a kind in a status, latest first
+	List(ctx context.Context, kind, status string, limit int64) ([]OutboxMessage, error)
+	// DeletePending removes pending messages of a kind which belong to an account
+	DeletePending(ctx context.Context, kind, owner string) error
+}
+
+// OutboxMessage is a message in the outbox
+type OutboxMessage struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Kind     string             `bson:"kind" json:"kind"`
+	Owner    string             `bson:"owner,omitempty" json:"-"`
+	DedupKey string             `bson:"dedup_key,omitempty" json:"dedup_key,omitempty"`
+	Payload  bson.Raw           `bson:"payload" json:"-"`
+	Status   string             `bson:"status" json:"status"`
+	// Version increases whenever the message is replaced
+	Version       int64  `bson:"version" json:"version"`
+	Attempts      int    `bson:"attempts" json:"attempts"`
+	NextAttemptAt int64  `bson:"next_attempt_at" json:"next_attempt_at"`
+	LastError     string `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	CreatedAt     int64  `bson:"created_at" json:"created_at"`
+	UpdatedAt     int64  `bson:"updated_at" json:"updated_at"`
+}
+
+// NewOutboxMessage returns a message with the payload encoded
+func NewOutboxMessage(kind, owner, dedupKey string, payload interface{}) (OutboxMessage, error) {
+	data, err := bson.Marshal(payload)
+	if err != nil {
+		return OutboxMessage{}, err
+	}
+
+	return OutboxMessage{
+		Kind:     kind,
+		Owner:    owner,
+		DedupKey: dedupKey,
+		Payload:  data,
+	}, nil
+}
+
+// DecodePayload decodes the payload of the message into v
+func (msg OutboxMessage) DecodePayload(v interface{}) error {
+	return bson.Unmarshal(msg.Payload, v)
+}
+
+type mongoOutboxStore struct {
+	collection *mongo.Collection
+}
+
+func nowInMillisecond() int64 {
+	return time.Now().UTC().UnixNano() / int64(time.Millisecond)
+}
+
+// Outbox returns the outbox store
+func (m mongodbDataPool) Outbox() OutboxStore {
+	dbName := fmt.Sprintf("%soutbox", m.dbPrefix)
+	return &mongoOutboxStore{
+		collection: m.client.Database(dbName).Collection("messages"),
+	}
+}
+
+// InitOutboxStore creates indexes of the outbox store
+func (m mongodbDataPool) InitOutboxStore() error {
+	dbName := fmt.Sprintf("%soutbox", m.dbPrefix)
+	_, err := m.client.Database(dbName).Collection("messages").Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{"kind", 1},
+				{"status", 1},
+				{"next_attempt_at", 1},
+			},
+			Options: options.Index().SetName("kind_status_next_attempt"),
+		},
+		{
+			Keys: bson.D{
+				{"dedup_key", 1},
+			},
+			Options: options.Index().SetUnique(true).SetName("dedup_key_pending_unique").
+				SetPartialFilterExpression(bson.M{"status": OutboxStatusPending, "dedup_key": bson.M{"$exists": true}}),
+		},
+	})
+	return err
+}
+
+func (m *mongoOutboxStore) Enqueue(ctx context.Context, msg OutboxMessage) error {
+	now := nowInMillisecond()
+	if msg.DedupKey == "" {
+		_, err := m.collection.InsertOne(ctx, bson.M{
+			"kind":            msg.Kind,
+			"owner":           msg.Owner,
+			"payload":         msg.Payload,
+			"status":          OutboxStatusPending,
+			"version":         1,
+			"attempts":        0,
+			"next_attempt_at": now,
+			"created_at":      now,
+			"updated_at":      now,
+		})
+		return err
+	}
+
+	_, err := m.collection.UpdateOne(ctx,
+		bson.M{"dedup_key": msg.DedupKey, "status": OutboxStatusPending},
+		bson.M{
+			"$set": bson.M{
+				"payload":         msg.Payload,
+				"attempts":        0,
+				"next_attempt_at": now,
+				"updated_at":      now,
+			},
+			"$inc": bson.M{"version": 1},
+			// dedup_key and status are taken from the filter on insert
+			"$setOnInsert": bson.M{
+				"kind":       msg.Kind,
+				"owner":      msg.Owner,
+				"created_at": now,
+			},
+		},
+		options.Update().SetUpsert(true))
+	return err
+}
+
+func (m *mongoOutboxStore) Claim(ctx context.Context, kind string, lease time.Duration) (*OutboxMessage, error) {
+	now := nowInMillisecond()
+	var msg OutboxMessage
+	err := m.collection.FindOneAndUpdate(ctx,
+		bson.M{
+			"kind":            kind,
+			"status":          OutboxStatusPending,
+			"next_attempt_at": bson.M{"$lte": now},
+		},
+		bson.M{
+			"$set": bson.M{"next_attempt_at": now + int64(lease/time.Millisecond)},
+			"$inc": bson.M{"attempts": 1},
+		},
+		options.FindOneAndUpdate().
+			SetSort(bson.D{{"next_attempt_at", 1}, {"_id", 1}}).
+			SetReturnDocument(options.After)).Decode(&msg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &msg, nil
+}
+
+func (m *mongoOutboxStore) MarkDelivered(ctx context.Context, msg *OutboxMessage) error {
+	_, err := m.collection.UpdateOne(ctx,
+		bson.M{"_id": msg.ID, "version": msg.Version},
+		bson.M{"$set": bson.M{
+			"status":     OutboxStatusDelivered,
+			"updated_at": nowInMillisecond(),
+		}})
+	return err
+}
+
+func (m *mongoOutboxStore) MarkRetry(ctx context.Context, msg *OutboxMessage, retryAt time.Time, reason string) error {
+	_, err := m.collection.UpdateOne(ctx,
+		bson.M{"_id": msg.ID, "version": msg.Version},
+		bson.M{"$set": bson.M{
+			"next_attempt_at": retryAt.UTC().UnixNano() / int64(time.Millisecond),
+			"last_error":      reason,
+			"updated_at":      nowInMillisecond(),
+		}})
+	return err
+}
+
+func (m *mongoOutboxStore) MarkFailed(ctx context.Context, msg *OutboxMessage, reason string) error {
+	_, err := m.collection.UpdateOne(ctx,
+		bson.M{"_id": msg.ID, "version": msg.Version},
+		bson.M{"$set": bson.M{
+			"status":     OutboxStatusFailed,
+			"last_error": reason,
+			"updated_at": nowInMillisecond(),
+		}})
+	return err
+}
+
+func (m *mongoOutboxStore) List(ctx context.Context, kind, status string, limit int64) ([]OutboxMessage, error) {
+	filter := bson.M{"kind": kind}
+	if status != "" {
+		filter["status"] = status
+	}
+
+	cursor, err := m.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{"_id", -1}}).SetLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+
+	messages := []OutboxMessage{}
+	if err := cursor.All(ctx, &messages); err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+func (m *mongoOutboxStore) DeletePending(ctx context.Context, kind, owner string) error {
+	_, err := m.collection.DeleteMany(ctx, bson.M{"kind": kind, "owner": owner, "status": OutboxStatusPending})
+	return err
+}
@@ -0,0 +1,143 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type OutboxTestSuite struct {
+	suite.Suite
+	connURI     string
+	mongoClient *mongo.Client
+}
+
+func NewOutboxTestSuite(connURI string) *OutboxTestSuite {
+	return &OutboxTestSuite{
+		connURI: connURI,
+	}
+}
+
+func (s *OutboxTestSuite) SetupSuite() {
+	if s.connURI == "" {
+		s.T().Fatal("invalid test suite configuration")
+	}
+
+	opts := options.Client().ApplyURI(s.connURI)
+	mongoClient, err := mongo.NewClient(opts)
+	if nil != err {
+		s.T().Fatalf("create mongo client with error: %s", err)
+	}
+
+	ctx := context.Background()
+	if err = mongoClient.Connect(ctx); nil != err {
+		s.T().Fatalf("connect mongo database with error: %s", err.Error())
+	}
+
+	s.mongoClient = mongoClient
+}
+
+func (s *OutboxTestSuite) SetupTest() {
+	ctx := context.Background()
+	dbNames, err := s.mongoClient.ListDatabaseNames(ctx, bson.M{"name": primitive.Regex{Pattern: "^testcase_"}})
+	if err != nil {
+		s.T().Fatalf("list all databases with error: %s", err.Error())
+	}
+
+	for _, name := range dbNames {
+		if err := s.mongoClient.Database(name).Drop(ctx); err != nil {
+			s.T().Fatalf("drop database with error: %s", err.Error())
+		}
+	}
+
+	if err := newTestDataPool(s.mongoClient).InitOutboxStore(); err != nil {
+		s.T().Fatalf("init outbox store with error: %s", err.Error())
+	}
+}
+
+func (s *OutboxTestSuite) TestEnqueueWithDedupKey() {
+	ctx := context.Background()
+	outbox := newTestDataPool(s.mongoClient).Outbox()
+
+	for _, score := range []int{1, 2} {
+		msg, err := NewOutboxMessage("test", "account", "test:account", bson.M{"score": score})
+		s.NoError(err)
+		s.NoError(outbox.Enqueue(ctx, msg))
+	}
+
+	messages, err := outbox.List(ctx, "test", OutboxStatusPending, 10)
+	s.NoError(err)
+	s.Len(messages, 1)
+	s.Equal(int64(2), messages[0].Version)
+
+	var payload struct {
+		Score int `bson:"score"`
+	}
+	s.NoError(messages[0].DecodePayload(&payload))
+	s.Equal(2, payload.Score)
+}
+
+func (s *OutboxTestSuite) TestClaimAndMark() {
+	ctx := context.Background()
+	outbox := newTestDataPool(s.mongoClient).Outbox()
+
+	msg, err := NewOutboxMessage("test", "account", "test:account", bson.M{"score": 1})
+	s.NoError(err)
+	s.NoError(outbox.Enqueue(ctx, msg))
+
+	claimed, err := outbox.Claim(ctx, "test", time.Minute)
+	s.NoError(err)
+	s.Equal(1, claimed.Attempts)
+
+	// the message is hidden during the lease
+	_, err = outbox.Claim(ctx, "test", time.Minute)
+	s.Equal(mongo.ErrNoDocuments, err)
+
+	s.NoError(outbox.MarkRetry(ctx, claimed, time.Now().Add(-time.Second), "unavailable"))
+	claimed, err = outbox.Claim(ctx, "test", time.Minute)
+	s.NoError(err)
+	s.Equal(2, claimed.Attempts)
+	s.Equal("unavailable", claimed.LastError)
+
+	s.NoError(outbox.MarkDelivered(ctx, claimed))
+	messages, err := outbox.List(ctx, "test", OutboxStatusDelivered, 10)
+	s.NoError(err)
+	s.Len(messages, 1)
+}
+
+func (s *OutboxTestSuite) TestReplacedWhileClaimed() {
+	ctx := context.Background()
+	outbox := newTestDataPool(s.mongoClient).Outbox()
+
+	msg, err := NewOutboxMessage("test", "account", "test:account", bson.M{"score": 1})
+	s.NoError(err)
+	s.NoError(outbox.Enqueue(ctx, msg))
+
+	claimed, err := outbox.Claim(ctx, "test", time.Minute)
+	s.NoError(err)
+
+	msg, err = NewOutboxMessage("test", "account", "test:account", bson.M{"score": 2})
+	s.NoError(err)
+	s.NoError(outbox.Enqueue(ctx, msg))
+
+	// the delivery of the old payload must not mark the new one as delivered
+	s.NoError(outbox.MarkDelivered(ctx, claimed))
+	messages, err := outbox.List(ctx, "test", OutboxStatusPending, 10)
+	s.NoError(err)
+	s.Len(messages, 1)
+
+	s.NoError(outbox.DeletePending(ctx, "test", "account"))
+	messages, err = outbox.List(ctx, "test", OutboxStatusPending, 10)
+	s.NoError(err)
+	s.Len(messages, 0)
+}
+
+func TestOutbox(t *testing.T) {
+	suite.Run(t, NewOutboxTestSuite("mongodb://127.0.0.1:27017/?compressors=disabled"))
+}
@@ -10,15 +10,108 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+func init() {
+	PersonalResources.Register(Resource{
+		Name:       "poi_ratings",
+		Collection: "poi_ratings",
+		Indexes: []mongo.IndexModel{
+			{
+				Keys: bson.D{
+					{"id", 1},
+				},
+				Options: options.Index().SetUnique(true).SetName("id_unique"),
+			},
+		},
+		Export: ExportJSON,
+		Delete: DeleteAccountData,
+	})
+
+	CommunityResources.Register(Resource{
+		Name:       "poi_ratings",
+		Collection: "poi_ratings",
+		Indexes: []mongo.IndexModel{
+			{
+				Keys: bson.D{
+					{"account_pseudonym", 1},
+					{"id", 1},
+				},
+				Options: options.Index().SetUnique(true).SetName("id_account_pseudonym_unique"),
+			},
+			{
+				Keys: bson.D{
+					{"id", 1},
+				},
+				Options: options.Index().SetName("id"),
+			},
+		},
+		OwnerKey: "account_pseudonym",
+		Export:   ExportJSON,
+		Delete:   DeleteAccountData,
+	})
+
+	PersonalResources.Register(Resource{
+		Name:       "poi_rating_history",
+		Collection: "poi_rating_history",
+		Indexes: []mongo.IndexModel{
+			{
+				Keys: bson.D{
+					{"id", 1},
+					{"timestamp", 1},
+				},
+				Options: options.Index().SetName("id_timestamp"),
+			},
+		},
+		Export: ExportJSON,
+		Delete: DeleteAccountData,
+	})
+
+	CommunityResources.Register(Resource{
+		Name:       "poi_rating_history",
+		Collection: "poi_rating_history",
+		Indexes: []mongo.IndexModel{
+			{
+				Keys: bson.D{
+					{"id", 1},
+					{"timestamp", -1},
+				},
+				Options: options.Index().SetName("id_timestamp"),
+			},
+			{
+				Keys: bson.D{
+					{"account_pseudonym", 1},
+				},
+				Options: options.Index().SetName("account_pseudonym"),
+			},
+			{
+				Keys: bson.D{
+					{"timestamp", -1},
+				},
+				Options: options.Index().SetName("timestamp"),
+			},
+		},
+		OwnerKey: "account_pseudonym",
+		Export:   ExportJSON,
+		Delete:   DeleteAccountData,
+	})
+}
+
 type POIResourceRating struct {
 	Ratings map[string]float64 `bson:"ratings"`
 }
 
+// POIRatingRecord is an entry of the rating history of a POI
+type POIRatingRecord struct {
+	Ratings   map[string]float64 `bson:"ratings" json:"ratings"`
+	Timestamp int64              `bson:"timestamp" json:"timestamp"`
+}
+
+// SetPOIRating updates the current rating of a POI and appends it to the rating history
 func (m *mongoAccountStore) SetPOIRating(ctx context.Context, poiID string, ratings map[string]float64) error {
+	ts := time.Now().UTC().UnixNano() / int64(time.Millisecond)
 	_, err := m.Resource("poi_ratings").UpdateOne(ctx,
 		bson.M{"id": poiID},
 		bson.M{
-			"$set":         bson.M{"ratings": ratings, "timestamp": time.Now().UTC().UnixNano() / int64(time.Millisecond)},
+			"$set":         bson.M{"ratings": ratings, "timestamp": ts},
 			"$setOnInsert": bson.M{"id": poiID},
 		},
 		options.Update().SetUpsert(true))
@@ -26,6 +119,14 @@ func (m *mongoAccountStore) SetPOIRating(ctx context.Context, poiID string, rati
 		return err
 	}
 
+	if _, err := m.Resource("poi_rating_history").InsertOne(ctx, bson.M{
+		"id":        poiID,
+		"ratings":   ratings,
+		"timestamp": ts,
+	}); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -39,66 +140,286 @@ func (m *mongoAccountStore) GetPOIRating(ctx context.Context, poiID string) (map
 	return rating.Ratings, nil
 }
 
+// GetPOIRatingHistory returns all ratings ever given to a POI in chronological order
+func (m *mongoAccountStore) GetPOIRatingHistory(ctx context.Context, poiID string) ([]POIRatingRecord, error) {
+	cursor, err := m.Resource("poi_rating_history").Find(ctx, bson.M{"id": poiID}, options.Find().SetSort(bson.D{{"timestamp", 1}}))
+	if err != nil {
+		return nil, err
+	}
+
+	history := []POIRatingRecord{}
+	if err := cursor.All(ctx, &history); err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+// SetPOIRating updates the current rating of a POI from an account and appends it to the rating history
 func (m *mongoCommunityStore) SetPOIRating(ctx context.Context, accountNumber, poiID string, ratings map[string]float64) error {
+	ts := time.Now().UTC().UnixNano() / int64(time.Millisecond)
+	pseudonym := m.accountPseudonym(accountNumber)
 	_, err := m.Resource("poi_ratings").UpdateOne(ctx,
-		bson.M{"id": poiID, "account_number": accountNumber},
+		bson.M{"id": poiID, "account_pseudonym": pseudonym},
 		bson.M{
-			"$set":         bson.M{"ratings": ratings, "timestamp": time.Now().UTC().UnixNano() / int64(time.Millisecond)},
-			"$setOnInsert": bson.M{"id": poiID, "account_number": accountNumber},
+			"$set":         bson.M{"ratings": ratings, "timestamp": ts},
+			"$setOnInsert": bson.M{"id": poiID, "account_pseudonym": pseudonym},
 		},
 		options.Update().SetUpsert(true))
 	if err != nil {
 		return err
 	}
 
+	if _, err := m.Resource("poi_rating_history").InsertOne(ctx, bson.M{
+		"id":                poiID,
+		"account_pseudonym": pseudonym,
+		"ratings":           ratings,
+		"timestamp":         ts,
+	}); err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// backfillPOIRatingHistory seeds an empty rating history with the current ratings, so that
+// summaries as of a time before the history is introduced still include them
+func backfillPOIRatingHistory(ctx context.Context, db *mongo.Database) error {
+	count, err := db.Collection("poi_rating_history").CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	cursor, err := db.Collection("poi_ratings").Find(ctx, bson.M{},
+		options.Find().SetProjection(bson.M{"_id": 0, "id": 1, "account_pseudonym": 1, "ratings": 1, "timestamp": 1}))
+	if err != nil {
+		return err
+	}
+
+	var records []interface{}
+	if err := cursor.All(ctx, &records); err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return nil
+	}
+
+	_, err = db.Collection("poi_rating_history").InsertMany(ctx, records)
+	return err
+}
+
 type RatingInfo struct {
-	Score  float64 `bson:"score" json:"score"`
-	Counts int     `bson:"counts" json:"counts"`
+	Score              float64        `bson:"score" json:"score"`
+	Counts             int            `bson:"counts" json:"counts"`
+	BayesianScore      *float64       `bson:"-" json:"bayesian_score,omitempty"`
+	ConfidenceInterval *[2]float64    `bson:"-" json:"confidence_interval,omitempty"`
+	StdDev             *float64       `bson:"std_dev,omitempty" json:"std_dev,omitempty"`
+	Histogram          map[string]int `bson:"histogram,omitempty" json:"histogram,omitempty"`
+
+	// sums of weights, squared weights and weighted squared values, used to derive the
+	// bayesian score and the confidence interval
+	Weight          float64 `bson:"sw" json:"-"`
+	WeightSquares   float64 `bson:"sww" json:"-"`
+	WeightedSquares float64 `bson:"swvv" json:"-"`
 }
 
 type POISummarizedRating struct {
-	ID            string                `bson:"_id" json:"id"`
-	LastUpdated   int64                 `bson:"last_updated" json:"last_updated"`
-	AverageRating float64               `bson:"rating_avg" json:"rating_avg"`
-	RatingCount   int64                 `bson:"rating_counts" json:"rating_counts"`
-	Ratings       map[string]RatingInfo `bson:"ratings" json:"ratings"`
+	ID                    string                `bson:"_id" json:"id"`
+	LastUpdated           int64                 `bson:"last_updated" json:"last_updated"`
+	AverageRating         float64               `bson:"rating_avg" json:"rating_avg"`
+	BayesianAverageRating *float64              `bson:"-" json:"bayesian_rating_avg,omitempty"`
+	RatingCount           int64                 `bson:"rating_counts" json:"rating_counts"`
+	Ratings               map[string]RatingInfo `bson:"ratings" json:"ratings"`
+	// Suppressed is set when the POI is rated by too few accounts to be summarized
+	Suppressed bool `bson:"-" json:"suppressed,omitempty"`
+	// Noised is set when the summary is perturbed for differential privacy
+	Noised bool `bson:"-" json:"noised,omitempty"`
 }
 
-func (m *mongoCommunityStore) GetPOISummarizedRatings(ctx context.Context, poiIDs []string) (map[string]POISummarizedRating, error) {
-	log.WithField("ids", poiIDs).Info("get poi rating summary")
-	cursor, err := m.Resource("poi_ratings").Aggregate(ctx,
-		mongo.Pipeline{
+// POISummaryOption is an option for GetPOISummarizedRatings
+type POISummaryOption struct {
+	// AsOf summarizes the latest ratings of each account given before the time in milliseconds
+	AsOf int64
+	// Since only summarizes ratings given after the time in milliseconds
+	Since int64
+	// HalfLife weights ratings by exponential decay of their age. A rating loses
+	// half of its weight every HalfLife. Zero means all ratings weigh the same.
+	HalfLife time.Duration
+	// Prior enables bayesian scores which pull scores of rarely rated POIs towards the prior
+	Prior *BayesianPrior
+	// Distribution includes histograms and standard deviations of rating values
+	Distribution bool
+	// MinContributors suppresses summaries, rating keys and histogram buckets which
+	// are counted from less ratings. Zero means nothing is suppressed.
+	MinContributors int
+}
+
+// weight returns the aggregation expression of the weight of a rating
+func (o POISummaryOption) weight() interface{} {
+	if o.HalfLife <= 0 {
+		return 1
+	}
+
+	now := o.AsOf
+	if now == 0 {
+		now = time.Now().UTC().UnixNano() / int64(time.Millisecond)
+	}
+
+	return bson.M{"$pow": bson.A{
+		0.5,
+		bson.M{"$divide": bson.A{
+			bson.M{"$subtract": bson.A{now, "$timestamp"}},
+			float64(o.HalfLife / time.Millisecond),
+		}},
+	}}
+}
+
+// poiRatingSource returns the collection and the beginning stages of a pipeline which
+// result in the latest rating of each account for the given POIs
+func (m *mongoCommunityStore) poiRatingSource(poiIDs []string, option POISummaryOption) (*mongo.Collection, mongo.Pipeline) {
+	collection, pipeline := m.latestPOIRatings(poiIDs, option)
+	if option.Since != 0 {
+		pipeline = append(pipeline, AggregationMatch(bson.M{"timestamp": bson.M{"$gte": option.Since}}))
+	}
+	return collection, pipeline
+}
+
+// latestPOIRatings returns the collection and the pipeline stages which result in the latest
+// rating of each account for the given POIs, as of a specific time if given
+func (m *mongoCommunityStore) latestPOIRatings(poiIDs []string, option POISummaryOption) (*mongo.Collection, mongo.Pipeline) {
+	if option.AsOf == 0 {
+		return m.Resource("poi_ratings"), mongo.Pipeline{
 			AggregationMatch(bson.M{"id": bson.M{"$in": poiIDs}}),
-			AggregationAddFields(bson.M{
-				"rating": bson.M{"$objectToArray": "$ratings"},
-			}),
-			AggregationUnwind("$rating"),
-			AggregationGroup(bson.M{
-				"k":  "$rating.k",
-				"id": "$id",
-			}, bson.D{
-				bson.E{"v", bson.M{"$avg": "$rating.v"}},
-				bson.E{"c", bson.M{"$sum": 1}},
-				bson.E{"last_updated", bson.M{"$max": "$timestamp"}},
-			}),
-			AggregationGroup("$_id.id", bson.D{
+		}
+	}
+
+	return m.Resource("poi_rating_history"), mongo.Pipeline{
+		AggregationMatch(bson.M{
+			"id":        bson.M{"$in": poiIDs},
+			"timestamp": bson.M{"$lte": option.AsOf},
+		}),
+		AggregationSort("timestamp", -1),
+		AggregationGroup(bson.M{
+			"id":                "$id",
+			"account_pseudonym": "$account_pseudonym",
+		}, bson.D{
+			bson.E{"ratings", bson.M{"$first": "$ratings"}},
+			bson.E{"timestamp", bson.M{"$first": "$timestamp"}},
+		}),
+		AggregationProject(bson.M{
+			"_id":               0,
+			"id":                "$_id.id",
+			"account_pseudonym": "$_id.account_pseudonym",
+			"ratings":           1,
+			"timestamp":         1,
+		}),
+	}
+}
+
+// poiSummaryStages returns the pipeline stages which summarize ratings from accounts
+// into ratings of POIs
+func poiSummaryStages(option POISummaryOption) mongo.Pipeline {
+	key := bson.M{
+		"k":  "$rating.k",
+		"id": "$id",
+	}
+	if option.Distribution {
+		// values are bucketed by their integer parts
+		key["b"] = bson.M{"$floor": "$rating.v"}
+	}
+
+	stages := mongo.Pipeline{
+		AggregationAddFields(bson.M{
+			"rating": bson.M{"$objectToArray": "$ratings"},
+			"w":      option.weight(),
+		}),
+		AggregationUnwind("$rating"),
+		AggregationGroup(key, bson.D{
+			bson.E{"sw", bson.M{"$sum": "$w"}},
+			bson.E{"swv", bson.M{"$sum": bson.M{"$multiply": bson.A{"$w", "$rating.v"}}}},
+			bson.E{"sww", bson.M{"$sum": bson.M{"$multiply": bson.A{"$w", "$w"}}}},
+			bson.E{"swvv", bson.M{"$sum": bson.M{"$multiply": bson.A{"$w", "$rating.v", "$rating.v"}}}},
+			bson.E{"c", bson.M{"$sum": 1}},
+			bson.E{"last_updated", bson.M{"$max": "$timestamp"}},
+		}),
+	}
+
+	fields := bson.M{
+		"v": bson.M{"$cond": bson.A{
+			bson.M{"$gt": bson.A{"$sw", 0}},
+			bson.M{"$divide": bson.A{"$swv", "$sw"}},
+			nil,
+		}},
+	}
+
+	rating := bson.M{
+		"score":  "$v",
+		"counts": bson.M{"$sum": "$c"},
+		"sw":     "$sw",
+		"sww":    "$sww",
+		"swvv":   "$swvv",
+	}
+
+	if option.Distribution {
+		// merge the buckets of a rating key into a histogram
+		stages = append(stages,
+			AggregationGroup(bson.M{"k": "$_id.k", "id": "$_id.id"}, bson.D{
+				bson.E{"sw", bson.M{"$sum": "$sw"}},
+				bson.E{"swv", bson.M{"$sum": "$swv"}},
+				bson.E{"sww", bson.M{"$sum": "$sww"}},
+				bson.E{"swvv", bson.M{"$sum": "$swvv"}},
+				bson.E{"c", bson.M{"$sum": "$c"}},
 				bson.E{"last_updated", bson.M{"$max": "$last_updated"}},
-				bson.E{"rating_avg", bson.M{"$avg": "$v"}},
-				bson.E{"ratings", bson.M{"$push": bson.M{
-					"k": "$_id.k",
-					"v": bson.M{
-						"score":  "$v",
-						"counts": bson.M{"$sum": "$c"},
-					},
+				bson.E{"histogram", bson.M{"$push": bson.M{
+					"k": bson.M{"$toString": "$_id.b"},
+					"v": "$c",
 				}}},
 			}),
-			AggregationAddFields(bson.M{
-				"ratings": bson.M{"$arrayToObject": "$ratings"},
-			}),
-		})
+		)
+
+		// weighted population standard deviation, sqrt(E[v^2] - E[v]^2)
+		fields["std_dev"] = bson.M{"$cond": bson.A{
+			bson.M{"$gt": bson.A{"$sw", 0}},
+			bson.M{"$sqrt": bson.M{"$max": bson.A{0, bson.M{"$subtract": bson.A{
+				bson.M{"$divide": bson.A{"$swvv", "$sw"}},
+				bson.M{"$pow": bson.A{bson.M{"$divide": bson.A{"$swv", "$sw"}}, 2}},
+			}}}}},
+			nil,
+		}}
+		fields["histogram"] = bson.M{"$arrayToObject": "$histogram"}
+
+		rating["std_dev"] = "$std_dev"
+		rating["histogram"] = "$histogram"
+	}
+
+	return append(stages,
+		AggregationAddFields(fields),
+		AggregationGroup("$_id.id", bson.D{
+			bson.E{"last_updated", bson.M{"$max": "$last_updated"}},
+			bson.E{"rating_avg", bson.M{"$avg": "$v"}},
+			bson.E{"ratings", bson.M{"$push": bson.M{
+				"k": "$_id.k",
+				"v": rating,
+			}}},
+		}),
+		AggregationAddFields(bson.M{
+			"ratings": bson.M{"$arrayToObject": "$ratings"},
+		}),
+	)
+}
+
+func (m *mongoCommunityStore) GetPOISummarizedRatings(ctx context.Context, poiIDs []string, opts ...POISummaryOption) (map[string]POISummarizedRating, error) {
+	var option POISummaryOption
+	if len(opts) > 0 {
+		option = opts[0]
+	}
+
+	log.WithField("ids", poiIDs).Info("get poi rating summary")
+	collection, source := m.poiRatingSource(poiIDs, option)
+	cursor, err := collection.Aggregate(ctx, append(source, poiSummaryStages(option)...))
 	if err != nil {
 		return nil, err
 	}
@@ -110,16 +431,20 @@ func (m *mongoCommunityStore) GetPOISummarizedRatings(ctx context.Context, poiID
 		if err := cursor.Decode(&r); err != nil {
 			return nil, err
 		}
+		r.adjustScores(option.Prior)
 		ratings[r.ID] = r
 	}
 
-	countCursor, err := m.Resource("poi_ratings").Aggregate(ctx,
-		mongo.Pipeline{
-			AggregationMatch(bson.M{"id": bson.M{"$in": poiIDs}}),
+	collection, source = m.poiRatingSource(poiIDs, option)
+	countCursor, err := collection.Aggregate(ctx,
+		append(source,
 			AggregationGroup("$id", bson.D{
 				bson.E{"rating_counts", bson.M{"$sum": 1}},
 			}),
-		})
+		))
+	if err != nil {
+		return nil, err
+	}
 
 	for countCursor.Next(ctx) {
 		var r POISummarizedRating
@@ -133,5 +458,12 @@ func (m *mongoCommunityStore) GetPOISummarizedRatings(ctx context.Context, poiID
 		ratings[r.ID] = targetRating
 	}
 
+	if option.MinContributors > 0 {
+		for id, r := range ratings {
+			r.suppress(option.MinContributors)
+			ratings[id] = r
+		}
+	}
+
 	return ratings, nil
 }
@@ -0,0 +1,202 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var ratingKeyPattern = regexp.MustCompile(`^[a-zA-Z0-9_\-]+$`)
+
+// POIRanking is the score of a POI on a rating key
+type POIRanking struct {
+	ID     string  `bson:"_id" json:"id"`
+	Score  float64 `bson:"score" json:"score"`
+	Counts int     `bson:"counts" json:"counts"`
+	// Change is the change of the score in the trending period
+	Change *float64 `bson:"-" json:"change,omitempty"`
+}
+
+// POIRankingOption is an option for ranking POIs
+type POIRankingOption struct {
+	// Key is the rating key to rank POIs by
+	Key string
+	// Limit is the maximum number of POIs returned
+	Limit int64
+	// MinCount excludes POIs rated by less accounts
+	MinCount int
+	// Near only ranks POIs within Radius meters of the point
+	Near   *GeoJSONPoint
+	Radius int
+}
+
+// ValidRatingKey checks whether a rating key can be used in queries
+func ValidRatingKey(key string) bool {
+	return ratingKeyPattern.MatchString(key)
+}
+
+func (o POIRankingOption) validate() error {
+	if !ValidRatingKey(o.Key) {
+		return fmt.Errorf("invalid rating key")
+	}
+	if o.Limit <= 0 {
+		return fmt.Errorf("invalid limit")
+	}
+	return nil
+}
+
+// keyScoreStages returns the pipeline stages that average the value of a rating key of each
+// POI, from documents which contain an `id` and a value `v`
+func keyScoreStages(minCount int) mongo.Pipeline {
+	return mongo.Pipeline{
+		AggregationMatch(bson.M{"v": bson.M{"$type": "number"}}),
+		AggregationGroup("$id", bson.D{
+			bson.E{"score", bson.M{"$avg": "$v"}},
+			bson.E{"counts", bson.M{"$sum": 1}},
+		}),
+		AggregationMatch(bson.M{"counts": bson.M{"$gte": minCount}}),
+	}
+}
+
+// GetTopPOIs returns POIs with the highest scores of a rating key
+func (m *mongoCommunityStore) GetTopPOIs(ctx context.Context, option POIRankingOption) ([]POIRanking, error) {
+	if err := option.validate(); err != nil {
+		return nil, err
+	}
+
+	var collection *mongo.Collection
+	var pipeline mongo.Pipeline
+	if option.Near != nil {
+		collection = m.Resource("pois")
+		pipeline = mongo.Pipeline{
+			AggregationGeoNear(option.Near.Coordinates, option.Radius, GeoNearOption{
+				GeoKey:      "location",
+				DistanceKey: "distance",
+			}),
+			bson.D{bson.E{"$lookup", bson.M{
+				"from":         "poi_ratings",
+				"localField":   "id",
+				"foreignField": "id",
+				"as":           "rating",
+			}}},
+			AggregationUnwind("$rating"),
+			AggregationProject(bson.M{"id": 1, "v": "$rating.ratings." + option.Key}),
+		}
+	} else {
+		collection = m.Resource("poi_ratings")
+		pipeline = mongo.Pipeline{
+			AggregationProject(bson.M{"id": 1, "v": "$ratings." + option.Key}),
+		}
+	}
+
+	pipeline = append(pipeline, keyScoreStages(option.MinCount)...)
+	pipeline = append(pipeline,
+		bson.D{bson.E{"$sort", bson.D{{"score", -1}, {"counts", -1}, {"_id", 1}}}},
+		AggregationLimit(option.Limit),
+	)
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	rankings := []POIRanking{}
+	if err := cursor.All(ctx, &rankings); err != nil {
+		return nil, err
+	}
+
+	return rankings, nil
+}
+
+// GetTrendingPOIs returns POIs whose scores of a rating key change the most in the past period.
+// The change of a POI is the difference between its score now and its score at the beginning of the period.
+func (m *mongoCommunityStore) GetTrendingPOIs(ctx context.Context, period time.Duration, option POIRankingOption) ([]POIRanking, error) {
+	if err := option.validate(); err != nil {
+		return nil, err
+	}
+
+	cutoff := time.Now().UTC().Add(-period).UnixNano() / int64(time.Millisecond)
+
+	// only POIs rated during the period can change
+	ids, err := m.Resource("poi_rating_history").Distinct(ctx, "id", bson.M{
+		"timestamp":             bson.M{"$gte": cutoff},
+		"ratings." + option.Key: bson.M{"$exists": true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return []POIRanking{}, nil
+	}
+
+	poiIDs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if s, ok := id.(string); ok {
+			poiIDs = append(poiIDs, s)
+		}
+	}
+
+	current, err := m.keyScores(ctx, poiIDs, option, POISummaryOption{})
+	if err != nil {
+		return nil, err
+	}
+
+	previous, err := m.keyScores(ctx, poiIDs, option, POISummaryOption{AsOf: cutoff})
+	if err != nil {
+		return nil, err
+	}
+
+	rankings := []POIRanking{}
+	for id, r := range current {
+		p, ok := previous[id]
+		if !ok {
+			// there is no score to compare with for POIs first rated during the period
+			continue
+		}
+
+		change := r.Score - p.Score
+		r.Change = &change
+		rankings = append(rankings, r)
+	}
+
+	sort.Slice(rankings, func(i, j int) bool {
+		if *rankings[i].Change == *rankings[j].Change {
+			return rankings[i].ID < rankings[j].ID
+		}
+		return *rankings[i].Change > *rankings[j].Change
+	})
+
+	if int64(len(rankings)) > option.Limit {
+		rankings = rankings[:option.Limit]
+	}
+
+	return rankings, nil
+}
+
+// keyScores returns the scores of a rating key of the given POIs
+func (m *mongoCommunityStore) keyScores(ctx context.Context, poiIDs []string, option POIRankingOption, summaryOption POISummaryOption) (map[string]POIRanking, error) {
+	collection, pipeline := m.latestPOIRatings(poiIDs, summaryOption)
+	pipeline = append(pipeline, AggregationProject(bson.M{"id": 1, "v": "$ratings." + option.Key}))
+	pipeline = append(pipeline, keyScoreStages(option.MinCount)...)
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	var rankings []POIRanking
+	if err := cursor.All(ctx, &rankings); err != nil {
+		return nil, err
+	}
+
+	scores := make(map[string]POIRanking, len(rankings))
+	for _, r := range rankings {
+		scores[r.ID] = r
+	}
+	return scores, nil
+}
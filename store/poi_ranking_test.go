@@ -0,0 +1,151 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type POIRankingTestSuite struct {
+	suite.Suite
+	connURI     string
+	mongoClient *mongo.Client
+}
+
+func NewPOIRankingTestSuite(connURI string) *POIRankingTestSuite {
+	return &POIRankingTestSuite{
+		connURI: connURI,
+	}
+}
+
+func (s *POIRankingTestSuite) SetupSuite() {
+	if s.connURI == "" {
+		s.T().Fatal("invalid test suite configuration")
+	}
+
+	opts := options.Client().ApplyURI(s.connURI)
+	mongoClient, err := mongo.NewClient(opts)
+	if nil != err {
+		s.T().Fatalf("create mongo client with error: %s", err)
+	}
+
+	ctx := context.Background()
+	if err = mongoClient.Connect(ctx); nil != err {
+		s.T().Fatalf("connect mongo database with error: %s", err.Error())
+	}
+
+	s.mongoClient = mongoClient
+
+	dbNames, err := mongoClient.ListDatabaseNames(ctx, bson.M{"name": primitive.Regex{Pattern: "^testcase_"}})
+	if err != nil {
+		s.T().Fatalf("list all databases with error: %s", err.Error())
+	}
+
+	for _, name := range dbNames {
+		if err := mongoClient.Database(name).Drop(ctx); err != nil {
+			s.T().Fatalf("drop database with error: %s", err.Error())
+		}
+	}
+
+	if err := newTestDataPool(s.mongoClient).InitCommunityStore(); err != nil {
+		s.T().Fatalf("init community store with error: %s", err.Error())
+	}
+
+	if err := s.LoadFixtures(); err != nil {
+		s.T().Fatalf("load fixtures with error: %s", err.Error())
+	}
+}
+
+func (s *POIRankingTestSuite) LoadFixtures() error {
+	ctx := context.Background()
+	db := s.mongoClient.Database(TestDBPrefix + "community")
+	now := time.Now().UTC()
+	daysAgo := func(days int) int64 {
+		return now.Add(-time.Duration(days)*24*time.Hour).UnixNano() / int64(time.Millisecond)
+	}
+
+	if _, err := db.Collection("poi_ratings").InsertMany(ctx, bson.A{
+		bson.M{"id": "rank-a", "account_pseudonym": "user1", "ratings": bson.M{"mask": 5}, "timestamp": daysAgo(1)},
+		bson.M{"id": "rank-a", "account_pseudonym": "user2", "ratings": bson.M{"mask": 4}, "timestamp": daysAgo(30)},
+		bson.M{"id": "rank-b", "account_pseudonym": "user1", "ratings": bson.M{"mask": 5}, "timestamp": daysAgo(1)},
+		bson.M{"id": "rank-c", "account_pseudonym": "user1", "ratings": bson.M{"mask": 2}, "timestamp": daysAgo(2)},
+		bson.M{"id": "rank-c", "account_pseudonym": "user2", "ratings": bson.M{"mask": 3}, "timestamp": daysAgo(2)},
+		bson.M{"id": "rank-c", "account_pseudonym": "user3", "ratings": bson.M{"mask": 1, "distancing": 5}, "timestamp": daysAgo(2)},
+	}); err != nil {
+		return err
+	}
+
+	if _, err := db.Collection("poi_rating_history").InsertMany(ctx, bson.A{
+		bson.M{"id": "rank-a", "account_pseudonym": "user1", "ratings": bson.M{"mask": 1}, "timestamp": daysAgo(30)},
+		bson.M{"id": "rank-a", "account_pseudonym": "user2", "ratings": bson.M{"mask": 4}, "timestamp": daysAgo(30)},
+		bson.M{"id": "rank-a", "account_pseudonym": "user1", "ratings": bson.M{"mask": 5}, "timestamp": daysAgo(1)},
+		bson.M{"id": "rank-b", "account_pseudonym": "user1", "ratings": bson.M{"mask": 5}, "timestamp": daysAgo(1)},
+		bson.M{"id": "rank-c", "account_pseudonym": "user1", "ratings": bson.M{"mask": 4}, "timestamp": daysAgo(30)},
+		bson.M{"id": "rank-c", "account_pseudonym": "user1", "ratings": bson.M{"mask": 2}, "timestamp": daysAgo(2)},
+		bson.M{"id": "rank-c", "account_pseudonym": "user2", "ratings": bson.M{"mask": 3}, "timestamp": daysAgo(2)},
+		bson.M{"id": "rank-c", "account_pseudonym": "user3", "ratings": bson.M{"mask": 1, "distancing": 5}, "timestamp": daysAgo(2)},
+	}); err != nil {
+		return err
+	}
+
+	community := newTestDataPool(s.mongoClient).Community()
+	if err := community.AddPOI(ctx, POI{ID: "rank-a", Name: "Sproul Plaza", Location: NewGeoJSONPoint(37.8696, -122.2593)}); err != nil {
+		return err
+	}
+	return community.AddPOI(ctx, POI{ID: "rank-c", Name: "San Francisco Airport", Location: NewGeoJSONPoint(37.6213, -122.3790)})
+}
+
+func (s *POIRankingTestSuite) TestGetTopPOIs() {
+	ctx := context.Background()
+	community := newTestDataPool(s.mongoClient).Community()
+
+	rankings, err := community.GetTopPOIs(ctx, POIRankingOption{Key: "mask", Limit: 10})
+	s.NoError(err)
+	s.Len(rankings, 3)
+	s.Equal("rank-b", rankings[0].ID)
+	s.Equal("rank-a", rankings[1].ID)
+	s.Equal(4.5, rankings[1].Score)
+	s.Equal(2, rankings[1].Counts)
+	s.Equal("rank-c", rankings[2].ID)
+
+	rankings, err = community.GetTopPOIs(ctx, POIRankingOption{Key: "mask", Limit: 1, MinCount: 2})
+	s.NoError(err)
+	s.Len(rankings, 1)
+	s.Equal("rank-a", rankings[0].ID)
+
+	rankings, err = community.GetTopPOIs(ctx, POIRankingOption{Key: "distancing", Limit: 10})
+	s.NoError(err)
+	s.Len(rankings, 1)
+	s.Equal("rank-c", rankings[0].ID)
+
+	near := NewGeoJSONPoint(37.8697, -122.2594)
+	rankings, err = community.GetTopPOIs(ctx, POIRankingOption{Key: "mask", Limit: 10, Near: &near, Radius: 1000})
+	s.NoError(err)
+	s.Len(rankings, 1)
+	s.Equal("rank-a", rankings[0].ID)
+	s.Equal(4.5, rankings[0].Score)
+
+	_, err = community.GetTopPOIs(ctx, POIRankingOption{Key: "mask.$x", Limit: 10})
+	s.Error(err)
+}
+
+func (s *POIRankingTestSuite) TestGetTrendingPOIs() {
+	ctx := context.Background()
+	rankings, err := newTestDataPool(s.mongoClient).Community().GetTrendingPOIs(ctx, 7*24*time.Hour, POIRankingOption{Key: "mask", Limit: 10})
+	s.NoError(err)
+	s.Len(rankings, 2)
+	s.Equal("rank-a", rankings[0].ID)
+	s.InDelta(2.0, *rankings[0].Change, 1e-9)
+	s.Equal("rank-c", rankings[1].ID)
+	s.InDelta(-2.0, *rankings[1].Change, 1e-9)
+}
+
+func TestPOIRanking(t *testing.T) {
+	suite.Run(t, NewPOIRankingTestSuite("mongodb://127.0.0.1:27017/?compressors=disabled"))
+}
@@ -0,0 +1,95 @@
+package store
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const maxNearbyPOIs = 100
+
+func init() {
+	// POIs are public places and are not linked to any account
+	CommunityResources.Register(Resource{
+		Name:       "pois",
+		Collection: "pois",
+		Indexes: []mongo.IndexModel{
+			{
+				Keys: bson.D{
+					{"id", 1},
+				},
+				Options: options.Index().SetUnique(true).SetName("id_unique"),
+			},
+			{
+				Keys: bson.D{
+					{"location", "2dsphere"},
+				},
+				Options: options.Index().SetName("location_2dsphere"),
+			},
+		},
+		Delete: RetainAccountData,
+	})
+}
+
+// GeoJSONPoint is a point in GeoJSON format. Coordinates are in [longitude, latitude] order.
+type GeoJSONPoint struct {
+	Type        string    `bson:"type" json:"type"`
+	Coordinates []float64 `bson:"coordinates" json:"coordinates"`
+}
+
+// NewGeoJSONPoint returns a point of the given latitude and longitude
+func NewGeoJSONPoint(lat, lng float64) GeoJSONPoint {
+	return GeoJSONPoint{
+		Type:        "Point",
+		Coordinates: []float64{lng, lat},
+	}
+}
+
+// POI is a place which can be rated
+type POI struct {
+	ID       string       `bson:"id" json:"id"`
+	Name     string       `bson:"name" json:"name"`
+	Category string       `bson:"category" json:"category"`
+	Location GeoJSONPoint `bson:"location" json:"location"`
+	// Distance is the distance in meters from the point of a nearby search
+	Distance float64 `bson:"distance,omitempty" json:"distance,omitempty"`
+}
+
+// AddPOI creates or updates a POI
+func (m *mongoCommunityStore) AddPOI(ctx context.Context, poi POI) error {
+	_, err := m.Resource("pois").UpdateOne(ctx,
+		bson.M{"id": poi.ID},
+		bson.M{
+			"$set": bson.M{
+				"name":     poi.Name,
+				"category": poi.Category,
+				"location": poi.Location,
+			},
+			"$setOnInsert": bson.M{"id": poi.ID},
+		},
+		options.Update().SetUpsert(true))
+	return err
+}
+
+// GetNearbyPOIs returns POIs within the radius in meters of a location, ordered by distance
+func (m *mongoCommunityStore) GetNearbyPOIs(ctx context.Context, lat, lng float64, radius int) ([]POI, error) {
+	cursor, err := m.Resource("pois").Aggregate(ctx, mongo.Pipeline{
+		AggregationGeoNear([]float64{lng, lat}, radius, GeoNearOption{
+			GeoKey:      "location",
+			DistanceKey: "distance",
+		}),
+		AggregationLimit(maxNearbyPOIs),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pois := []POI{}
+	if err := cursor.All(ctx, &pois); err != nil {
+		return nil, err
+	}
+
+	return pois, nil
+}
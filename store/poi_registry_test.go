@@ -0,0 +1,115 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	// POIs around Sproul Plaza, UC Berkeley
+	testPOIs = []POI{
+		{ID: "poi-sproul", Name: "Sproul Plaza", Category: "plaza", Location: NewGeoJSONPoint(37.8696, -122.2593)},
+		{ID: "poi-library", Name: "Doe Library", Category: "library", Location: NewGeoJSONPoint(37.8722, -122.2592)},
+		{ID: "poi-sfo", Name: "San Francisco Airport", Category: "airport", Location: NewGeoJSONPoint(37.6213, -122.3790)},
+	}
+)
+
+type POIRegistryTestSuite struct {
+	suite.Suite
+	connURI     string
+	mongoClient *mongo.Client
+}
+
+func NewPOIRegistryTestSuite(connURI string) *POIRegistryTestSuite {
+	return &POIRegistryTestSuite{
+		connURI: connURI,
+	}
+}
+
+func (s *POIRegistryTestSuite) SetupSuite() {
+	if s.connURI == "" {
+		s.T().Fatal("invalid test suite configuration")
+	}
+
+	opts := options.Client().ApplyURI(s.connURI)
+	mongoClient, err := mongo.NewClient(opts)
+	if nil != err {
+		s.T().Fatalf("create mongo client with error: %s", err)
+	}
+
+	ctx := context.Background()
+	if err = mongoClient.Connect(ctx); nil != err {
+		s.T().Fatalf("connect mongo database with error: %s", err.Error())
+	}
+
+	s.mongoClient = mongoClient
+
+	dbNames, err := mongoClient.ListDatabaseNames(ctx, bson.M{"name": primitive.Regex{Pattern: "^testcase_"}})
+	if err != nil {
+		s.T().Fatalf("list all databases with error: %s", err.Error())
+	}
+
+	for _, name := range dbNames {
+		if err := mongoClient.Database(name).Drop(ctx); err != nil {
+			s.T().Fatalf("drop database with error: %s", err.Error())
+		}
+	}
+
+	if err := newTestDataPool(s.mongoClient).InitCommunityStore(); err != nil {
+		s.T().Fatalf("init community store with error: %s", err.Error())
+	}
+
+	for _, poi := range testPOIs {
+		if err := newTestDataPool(s.mongoClient).Community().AddPOI(ctx, poi); err != nil {
+			s.T().Fatalf("add poi with error: %s", err.Error())
+		}
+	}
+}
+
+func (s *POIRegistryTestSuite) TestGetNearbyPOIs() {
+	ctx := context.Background()
+	community := newTestDataPool(s.mongoClient).Community()
+
+	pois, err := community.GetNearbyPOIs(ctx, 37.8697, -122.2594, 1000)
+	s.NoError(err)
+	s.Len(pois, 2)
+	s.Equal("poi-sproul", pois[0].ID)
+	s.Equal("Sproul Plaza", pois[0].Name)
+	s.Equal("plaza", pois[0].Category)
+	s.Equal("poi-library", pois[1].ID)
+	s.True(pois[0].Distance < pois[1].Distance)
+
+	pois, err = community.GetNearbyPOIs(ctx, 37.8697, -122.2594, 10)
+	s.NoError(err)
+	s.Len(pois, 1)
+
+	pois, err = community.GetNearbyPOIs(ctx, 0, 0, 1000)
+	s.NoError(err)
+	s.Len(pois, 0)
+}
+
+func (s *POIRegistryTestSuite) TestAddPOIUpdatesExisting() {
+	ctx := context.Background()
+	community := newTestDataPool(s.mongoClient).Community()
+
+	s.NoError(community.AddPOI(ctx, POI{ID: "poi-moved", Name: "Food Truck", Location: NewGeoJSONPoint(10, 10)}))
+	s.NoError(community.AddPOI(ctx, POI{ID: "poi-moved", Name: "Food Truck", Location: NewGeoJSONPoint(20, 20)}))
+
+	pois, err := community.GetNearbyPOIs(ctx, 20, 20, 100)
+	s.NoError(err)
+	s.Len(pois, 1)
+
+	pois, err = community.GetNearbyPOIs(ctx, 10, 10, 100)
+	s.NoError(err)
+	s.Len(pois, 0)
+}
+
+func TestPOIRegistry(t *testing.T) {
+	suite.Run(t, NewPOIRegistryTestSuite("mongodb://127.0.0.1:27017/?compressors=disabled"))
+}
@@ -0,0 +1,60 @@
+package store
+
+import (
+	"math"
+)
+
+// z-score of the two-sided 95% confidence interval
+const confidenceZScore = 1.959964
+
+// BayesianPrior is the prior belief of a rating. A bayesian score behaves as if
+// Weight ratings of the value Mean had been given in addition to the real ones.
+type BayesianPrior struct {
+	Mean   float64
+	Weight float64
+}
+
+// score returns the bayesian score for the sum of weighted values and the sum of weights
+func (p BayesianPrior) score(weightedSum, weight float64) float64 {
+	if p.Weight+weight == 0 {
+		return p.Mean
+	}
+	return (p.Mean*p.Weight + weightedSum) / (p.Weight + weight)
+}
+
+// confidenceInterval returns the 95% confidence interval of the score. It is
+// not available if the score is derived from less than two ratings.
+func (r RatingInfo) confidenceInterval() *[2]float64 {
+	if r.Weight <= 0 || r.WeightSquares <= 0 {
+		return nil
+	}
+
+	// effective sample size of weighted ratings, which equals to the count for unweighted ones
+	n := r.Weight * r.Weight / r.WeightSquares
+	if n <= 1 {
+		return nil
+	}
+
+	variance := math.Max(r.WeightedSquares/r.Weight-r.Score*r.Score, 0) * n / (n - 1)
+	margin := confidenceZScore * math.Sqrt(variance/n)
+	return &[2]float64{r.Score - margin, r.Score + margin}
+}
+
+// adjustScores fills confidence intervals, and bayesian scores if a prior is given
+func (s *POISummarizedRating) adjustScores(prior *BayesianPrior) {
+	bayesianSum := 0.0
+	for k, r := range s.Ratings {
+		r.ConfidenceInterval = r.confidenceInterval()
+		if prior != nil {
+			score := prior.score(r.Score*r.Weight, r.Weight)
+			r.BayesianScore = &score
+			bayesianSum += score
+		}
+		s.Ratings[k] = r
+	}
+
+	if prior != nil && len(s.Ratings) > 0 {
+		avg := bayesianSum / float64(len(s.Ratings))
+		s.BayesianAverageRating = &avg
+	}
+}
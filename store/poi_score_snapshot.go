@@ -0,0 +1,55 @@
+package store
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func init() {
+	// snapshots are summarized from ratings of many accounts and are not linked to any account
+	CommunityResources.Register(Resource{
+		Name:       "poi_score_snapshots",
+		Collection: "poi_score_snapshots",
+		Indexes: []mongo.IndexModel{
+			{
+				Keys: bson.D{
+					{"id", 1},
+				},
+				Options: options.Index().SetUnique(true).SetName("id_unique"),
+			},
+		},
+		Delete: RetainAccountData,
+	})
+}
+
+// POIScoreSnapshot is the community score of a POI when its raters are last notified of a change
+type POIScoreSnapshot struct {
+	ID          string  `bson:"id" json:"id"`
+	Score       float64 `bson:"score" json:"score"`
+	RatingCount int64   `bson:"rating_counts" json:"rating_counts"`
+	Timestamp   int64   `bson:"timestamp" json:"timestamp"`
+}
+
+// GetPOIScoreSnapshot returns the score snapshot of a POI, or nil if it is never taken
+func (m *mongoCommunityStore) GetPOIScoreSnapshot(ctx context.Context, poiID string) (*POIScoreSnapshot, error) {
+	var snapshot POIScoreSnapshot
+	if err := m.Resource("poi_score_snapshots").FindOne(ctx, bson.M{"id": poiID}).Decode(&snapshot); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &snapshot, nil
+}
+
+// SetPOIScoreSnapshot replaces the score snapshot of a POI
+func (m *mongoCommunityStore) SetPOIScoreSnapshot(ctx context.Context, snapshot POIScoreSnapshot) error {
+	snapshot.Timestamp = nowInMillisecond()
+	_, err := m.Resource("poi_score_snapshots").ReplaceOne(ctx,
+		bson.M{"id": snapshot.ID}, snapshot, options.Replace().SetUpsert(true))
+	return err
+}
@@ -0,0 +1,48 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBayesianPriorScore(t *testing.T) {
+	prior := BayesianPrior{Mean: 3, Weight: 5}
+	assert.Equal(t, 3.0, prior.score(0, 0))
+	// a single 5-star rating is pulled towards the prior
+	assert.InDelta(t, 20.0/6, prior.score(5, 1), 1e-9)
+	// many ratings dominate the prior
+	assert.InDelta(t, (15+300*4.8)/305, prior.score(300*4.8, 300), 1e-9)
+}
+
+func TestRatingConfidenceInterval(t *testing.T) {
+	// ratings 1 and 3
+	r := RatingInfo{Score: 2, Counts: 2, Weight: 2, WeightSquares: 2, WeightedSquares: 10}
+	interval := r.confidenceInterval()
+	if assert.NotNil(t, interval) {
+		assert.InDelta(t, 2-confidenceZScore, interval[0], 1e-9)
+		assert.InDelta(t, 2+confidenceZScore, interval[1], 1e-9)
+	}
+
+	// a single rating has no interval
+	r = RatingInfo{Score: 5, Counts: 1, Weight: 1, WeightSquares: 1, WeightedSquares: 25}
+	assert.Nil(t, r.confidenceInterval())
+}
+
+func TestPOISummarizedRatingAdjustScores(t *testing.T) {
+	summary := POISummarizedRating{
+		Ratings: map[string]RatingInfo{
+			"a": {Score: 5, Counts: 1, Weight: 1, WeightSquares: 1, WeightedSquares: 25},
+			"b": {Score: 2, Counts: 2, Weight: 2, WeightSquares: 2, WeightedSquares: 10},
+		},
+	}
+	summary.adjustScores(nil)
+	assert.Nil(t, summary.BayesianAverageRating)
+	assert.Nil(t, summary.Ratings["a"].BayesianScore)
+	assert.NotNil(t, summary.Ratings["b"].ConfidenceInterval)
+
+	summary.adjustScores(&BayesianPrior{Mean: 3, Weight: 1})
+	assert.InDelta(t, 4.0, *summary.Ratings["a"].BayesianScore, 1e-9)
+	assert.InDelta(t, 7.0/3, *summary.Ratings["b"].BayesianScore, 1e-9)
+	assert.InDelta(t, (4.0+7.0/3)/2, *summary.BayesianAverageRating, 1e-9)
+}
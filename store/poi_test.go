@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/suite"
 	"go.mongodb.org/mongo-driver/bson"
@@ -15,6 +16,19 @@ const (
 	TestDBPrefix = "testcase_"
 )
 
+var (
+	testPseudonymKey  = []byte("testcase pseudonym key")
+	testDefaultCohort = "testcase_cohort"
+)
+
+// newTestDataPool returns a data pool for test cases
+func newTestDataPool(client *mongo.Client) *mongodbDataPool {
+	pool := NewMongodbDataPool(client, TestDBPrefix)
+	pool.SetPseudonymKey(testPseudonymKey)
+	pool.SetDefaultCohort(testDefaultCohort)
+	return pool
+}
+
 var (
 	defaultRatingAccount = "account_default"
 	testGetPOIRatingID   = "test1234"
@@ -27,24 +41,48 @@ var (
 	testGetCommunityRatingID2 = "testCommunity5678"
 	defaultCommunityRatings   = bson.A{
 		map[string]interface{}{
-			"id":             testGetCommunityRatingID1,
-			"account_number": "user1",
-			"ratings":        map[string]float64{"a": 3, "b": 4},
+			"id":                testGetCommunityRatingID1,
+			"account_pseudonym": "user1",
+			"ratings":           map[string]float64{"a": 3, "b": 4},
+		},
+		map[string]interface{}{
+			"id":                testGetCommunityRatingID1,
+			"account_pseudonym": "user2",
+			"ratings":           map[string]float64{"a": 1, "b": 2},
+		},
+		map[string]interface{}{
+			"id":                testGetCommunityRatingID2,
+			"account_pseudonym": "user1",
+			"ratings":           map[string]float64{"a": 1, "b": 2, "c": 3},
 		},
 		map[string]interface{}{
-			"id":             testGetCommunityRatingID1,
-			"account_number": "user2",
-			"ratings":        map[string]float64{"a": 1, "b": 2},
+			"id":                testGetCommunityRatingID2,
+			"account_pseudonym": "user2",
+			"ratings":           map[string]float64{"a": 5, "b": 4, "c": 3},
+		},
+	}
+)
+
+var (
+	testCommunityHistoryID  = "testCommunityHistory"
+	defaultCommunityHistory = bson.A{
+		map[string]interface{}{
+			"id":                testCommunityHistoryID,
+			"account_pseudonym": "user1",
+			"ratings":           map[string]float64{"a": 1},
+			"timestamp":         1000,
 		},
 		map[string]interface{}{
-			"id":             testGetCommunityRatingID2,
-			"account_number": "user1",
-			"ratings":        map[string]float64{"a": 1, "b": 2, "c": 3},
+			"id":                testCommunityHistoryID,
+			"account_pseudonym": "user2",
+			"ratings":           map[string]float64{"a": 3},
+			"timestamp":         2000,
 		},
 		map[string]interface{}{
-			"id":             testGetCommunityRatingID2,
-			"account_number": "user2",
-			"ratings":        map[string]float64{"a": 5, "b": 4, "c": 3},
+			"id":                testCommunityHistoryID,
+			"account_pseudonym": "user1",
+			"ratings":           map[string]float64{"a": 5},
+			"timestamp":         3000,
 		},
 	}
 )
@@ -105,6 +143,9 @@ func (s *AccountPOITestSuite) LoadFixtures() error {
 	if _, err := s.mongoClient.Database("testcase_community").Collection("poi_ratings").InsertMany(ctx, defaultCommunityRatings); err != nil {
 		return err
 	}
+	if _, err := s.mongoClient.Database("testcase_community").Collection("poi_rating_history").InsertMany(ctx, defaultCommunityHistory); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -112,7 +153,7 @@ func (s *AccountPOITestSuite) TestAccountSetPOIRating() {
 	ctx := context.Background()
 	testAccount := "testcase_account1"
 	testPOIID := "abcd"
-	err := NewMongodbDataPool(s.mongoClient, TestDBPrefix).Account(testAccount).SetPOIRating(ctx, testPOIID, map[string]float64{
+	err := newTestDataPool(s.mongoClient).Account(testAccount).SetPOIRating(ctx, testPOIID, map[string]float64{
 		"a": 1,
 		"b": 2,
 		"c": 3,
@@ -137,7 +178,7 @@ func (s *AccountPOITestSuite) TestAccountSetPOIRating() {
 
 func (s *AccountPOITestSuite) TestAccountGetPOIRating() {
 	ctx := context.Background()
-	ratings, err := NewMongodbDataPool(s.mongoClient, TestDBPrefix).Account(defaultRatingAccount).GetPOIRating(ctx, testGetPOIRatingID)
+	ratings, err := newTestDataPool(s.mongoClient).Account(defaultRatingAccount).GetPOIRating(ctx, testGetPOIRatingID)
 	s.NoError(err)
 	s.Equal(ratings["a"], 1.0)
 }
@@ -146,7 +187,7 @@ func (s *AccountPOITestSuite) TestCommunitySetPOIRating() {
 	ctx := context.Background()
 	testAccount := "testcase_account1"
 	testPOIID := "abcd"
-	err := NewMongodbDataPool(s.mongoClient, TestDBPrefix).Community().SetPOIRating(ctx, testAccount, testPOIID, map[string]float64{
+	err := newTestDataPool(s.mongoClient).Community().SetPOIRating(ctx, testAccount, testPOIID, map[string]float64{
 		"a": 5,
 		"b": 4,
 		"c": 3,
@@ -158,11 +199,15 @@ func (s *AccountPOITestSuite) TestCommunitySetPOIRating() {
 	s.NoError(err)
 
 	var results []struct {
-		Ratings map[string]float64 `bson:"ratings"`
+		AccountNumber    string             `bson:"account_number"`
+		AccountPseudonym string             `bson:"account_pseudonym"`
+		Ratings          map[string]float64 `bson:"ratings"`
 	}
 	err = cursor.All(ctx, &results)
 	s.NoError(err)
 	s.Len(results, 1)
+	s.Empty(results[0].AccountNumber)
+	s.Equal(pseudonymize(testPseudonymKey, testAccount), results[0].AccountPseudonym)
 	s.Equal(results[0].Ratings["a"], 5.0)
 	s.Equal(results[0].Ratings["b"], 4.0)
 	s.Equal(results[0].Ratings["c"], 3.0)
@@ -171,7 +216,7 @@ func (s *AccountPOITestSuite) TestCommunitySetPOIRating() {
 
 func (s *AccountPOITestSuite) TestCommunityGetPOIRating() {
 	ctx := context.Background()
-	ratings, err := NewMongodbDataPool(s.mongoClient, TestDBPrefix).Community().GetPOISummarizedRatings(ctx, []string{testGetCommunityRatingID1, testGetCommunityRatingID2})
+	ratings, err := newTestDataPool(s.mongoClient).Community().GetPOISummarizedRatings(ctx, []string{testGetCommunityRatingID1, testGetCommunityRatingID2})
 	s.NoError(err)
 	s.Len(ratings, 2)
 	s.Equal(2.5, ratings[testGetCommunityRatingID1].AverageRating)
@@ -191,6 +236,85 @@ func (s *AccountPOITestSuite) TestCommunityGetPOIRating() {
 	s.Equal(int64(2), ratings[testGetCommunityRatingID2].RatingCount)
 }
 
+func (s *AccountPOITestSuite) TestAccountGetPOIRatingHistory() {
+	ctx := context.Background()
+	testAccount := "testcase_history_account"
+	testPOIID := "history"
+	store := newTestDataPool(s.mongoClient).Account(testAccount)
+	s.NoError(store.SetPOIRating(ctx, testPOIID, map[string]float64{"a": 1}))
+	s.NoError(store.SetPOIRating(ctx, testPOIID, map[string]float64{"a": 4}))
+
+	history, err := store.GetPOIRatingHistory(ctx, testPOIID)
+	s.NoError(err)
+	s.Len(history, 2)
+	s.Equal(1.0, history[0].Ratings["a"])
+	s.Equal(4.0, history[1].Ratings["a"])
+	s.True(history[0].Timestamp <= history[1].Timestamp)
+
+	ratings, err := store.GetPOIRating(ctx, testPOIID)
+	s.NoError(err)
+	s.Equal(4.0, ratings["a"])
+}
+
+func (s *AccountPOITestSuite) TestCommunityGetPOIRatingAsOf() {
+	ctx := context.Background()
+	community := newTestDataPool(s.mongoClient).Community()
+
+	ratings, err := community.GetPOISummarizedRatings(ctx, []string{testCommunityHistoryID}, POISummaryOption{AsOf: 2500})
+	s.NoError(err)
+	s.Len(ratings, 1)
+	s.Equal(2.0, ratings[testCommunityHistoryID].Ratings["a"].Score)
+	s.Equal(2, ratings[testCommunityHistoryID].Ratings["a"].Counts)
+	s.Equal(int64(2), ratings[testCommunityHistoryID].RatingCount)
+	s.Equal(int64(2000), ratings[testCommunityHistoryID].LastUpdated)
+
+	ratings, err = community.GetPOISummarizedRatings(ctx, []string{testCommunityHistoryID}, POISummaryOption{AsOf: 3000})
+	s.NoError(err)
+	s.Equal(4.0, ratings[testCommunityHistoryID].Ratings["a"].Score)
+
+	ratings, err = community.GetPOISummarizedRatings(ctx, []string{testCommunityHistoryID}, POISummaryOption{AsOf: 500})
+	s.NoError(err)
+	s.Len(ratings, 0)
+}
+
+func (s *AccountPOITestSuite) TestCommunityGetPOIRatingWindowAndDecay() {
+	ctx := context.Background()
+	community := newTestDataPool(s.mongoClient).Community()
+
+	ratings, err := community.GetPOISummarizedRatings(ctx, []string{testCommunityHistoryID}, POISummaryOption{AsOf: 3000, Since: 2500})
+	s.NoError(err)
+	s.Equal(5.0, ratings[testCommunityHistoryID].Ratings["a"].Score)
+	s.Equal(1, ratings[testCommunityHistoryID].Ratings["a"].Counts)
+	s.Equal(int64(1), ratings[testCommunityHistoryID].RatingCount)
+
+	ratings, err = community.GetPOISummarizedRatings(ctx, []string{testCommunityHistoryID}, POISummaryOption{AsOf: 3000, HalfLife: time.Second})
+	s.NoError(err)
+	s.InDelta(6.5/1.5, ratings[testCommunityHistoryID].Ratings["a"].Score, 1e-9)
+	s.Equal(2, ratings[testCommunityHistoryID].Ratings["a"].Counts)
+}
+
+func (s *AccountPOITestSuite) TestCommunityGetPOIRatingDistribution() {
+	ctx := context.Background()
+	ratings, err := newTestDataPool(s.mongoClient).Community().GetPOISummarizedRatings(ctx, []string{testGetCommunityRatingID2}, POISummaryOption{Distribution: true})
+	s.NoError(err)
+	s.Len(ratings, 1)
+
+	summary := ratings[testGetCommunityRatingID2]
+	s.Equal(3.0, summary.AverageRating)
+	s.Equal(map[string]int{"1": 1, "5": 1}, summary.Ratings["a"].Histogram)
+	s.Equal(map[string]int{"2": 1, "4": 1}, summary.Ratings["b"].Histogram)
+	s.Equal(map[string]int{"3": 2}, summary.Ratings["c"].Histogram)
+	s.InDelta(2.0, *summary.Ratings["a"].StdDev, 1e-9)
+	s.InDelta(1.0, *summary.Ratings["b"].StdDev, 1e-9)
+	s.InDelta(0.0, *summary.Ratings["c"].StdDev, 1e-9)
+	s.Equal(2, summary.Ratings["a"].Counts)
+
+	ratings, err = newTestDataPool(s.mongoClient).Community().GetPOISummarizedRatings(ctx, []string{testGetCommunityRatingID2})
+	s.NoError(err)
+	s.Nil(ratings[testGetCommunityRatingID2].Ratings["a"].Histogram)
+	s.Nil(ratings[testGetCommunityRatingID2].Ratings["a"].StdDev)
+}
+
 func TestAccountPOI(t *testing.T) {
 	suite.Run(t, NewAccountPOITestSuite("mongodb://127.0.0.1:27017/?compressors=disabled"))
 }
@@ -0,0 +1,75 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func init() {
+	// privacy budgets are kept per dataset and are not linked to any account
+	CommunityResources.Register(Resource{
+		Name:       "privacy_budgets",
+		Collection: "privacy_budgets",
+		Indexes: []mongo.IndexModel{
+			{
+				Keys: bson.D{
+					{"dataset", 1},
+				},
+				Options: options.Index().SetUnique(true).SetName("dataset_unique"),
+			},
+		},
+		Delete: RetainAccountData,
+	})
+}
+
+// ErrPrivacyBudgetExhausted is returned when a dataset can not be released with noise anymore
+var ErrPrivacyBudgetExhausted = errors.New("privacy budget exhausted")
+
+// SpendPrivacyBudget spends epsilon of the privacy budget of a dataset. It returns
+// ErrPrivacyBudgetExhausted if the total spent epsilon would exceed the limit. A positive
+// period replenishes the budget when the period since the budget is renewed elapses, while
+// zero never replenishes it.
+func (m *mongoCommunityStore) SpendPrivacyBudget(ctx context.Context, dataset string, epsilon, limit float64, period time.Duration) error {
+	now := nowInMillisecond()
+	if _, err := m.Resource("privacy_budgets").UpdateOne(ctx,
+		bson.M{"dataset": dataset},
+		bson.M{"$setOnInsert": bson.M{"dataset": dataset, "spent": 0.0, "renewed_at": now}},
+		options.Update().SetUpsert(true)); err != nil {
+		return err
+	}
+
+	if period > 0 {
+		if _, err := m.Resource("privacy_budgets").UpdateOne(ctx,
+			bson.M{
+				"dataset": dataset,
+				"$or": bson.A{
+					bson.M{"renewed_at": bson.M{"$exists": false}},
+					bson.M{"renewed_at": bson.M{"$lte": now - period.Milliseconds()}},
+				},
+			},
+			bson.M{"$set": bson.M{"spent": 0.0, "renewed_at": now}}); err != nil {
+			return err
+		}
+	}
+
+	result, err := m.Resource("privacy_budgets").UpdateOne(ctx,
+		bson.M{"dataset": dataset, "spent": bson.M{"$lte": limit - epsilon}},
+		bson.M{
+			"$inc": bson.M{"spent": epsilon},
+			"$set": bson.M{"updated_at": now},
+		})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrPrivacyBudgetExhausted
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type PrivacyBudgetTestSuite struct {
+	suite.Suite
+	connURI     string
+	mongoClient *mongo.Client
+}
+
+func NewPrivacyBudgetTestSuite(connURI string) *PrivacyBudgetTestSuite {
+	return &PrivacyBudgetTestSuite{
+		connURI: connURI,
+	}
+}
+
+func (s *PrivacyBudgetTestSuite) SetupSuite() {
+	if s.connURI == "" {
+		s.T().Fatal("invalid test suite configuration")
+	}
+
+	opts := options.Client().ApplyURI(s.connURI)
+	mongoClient, err := mongo.NewClient(opts)
+	if nil != err {
+		s.T().Fatalf("create mongo client with error: %s", err)
+	}
+
+	ctx := context.Background()
+	if err = mongoClient.Connect(ctx); nil != err {
+		s.T().Fatalf("connect mongo database with error: %s", err.Error())
+	}
+
+	s.mongoClient = mongoClient
+
+	dbNames, err := mongoClient.ListDatabaseNames(ctx, bson.M{"name": primitive.Regex{Pattern: "^testcase_"}})
+	if err != nil {
+		s.T().Fatalf("list all databases with error: %s", err.Error())
+	}
+
+	for _, name := range dbNames {
+		if err := mongoClient.Database(name).Drop(ctx); err != nil {
+			s.T().Fatalf("drop database with error: %s", err.Error())
+		}
+	}
+
+	if err := newTestDataPool(s.mongoClient).InitCommunityStore(); err != nil {
+		s.T().Fatalf("init community store with error: %s", err.Error())
+	}
+}
+
+func (s *PrivacyBudgetTestSuite) TestSpendPrivacyBudget() {
+	ctx := context.Background()
+	community := newTestDataPool(s.mongoClient).Community()
+
+	s.NoError(community.SpendPrivacyBudget(ctx, "budget-a", 1, 2, 0))
+	s.NoError(community.SpendPrivacyBudget(ctx, "budget-a", 1, 2, 0))
+	s.Equal(ErrPrivacyBudgetExhausted, community.SpendPrivacyBudget(ctx, "budget-a", 1, 2, 0))
+
+	// budgets of datasets are independent
+	s.NoError(community.SpendPrivacyBudget(ctx, "budget-b", 1, 2, 0))
+}
+
+func (s *PrivacyBudgetTestSuite) TestReplenishPrivacyBudget() {
+	ctx := context.Background()
+	community := newTestDataPool(s.mongoClient).Community()
+
+	s.NoError(community.SpendPrivacyBudget(ctx, "budget-c", 1, 1, time.Hour))
+	s.Equal(ErrPrivacyBudgetExhausted, community.SpendPrivacyBudget(ctx, "budget-c", 1, 1, time.Hour))
+
+	// the budget is renewed once the period elapses
+	_, err := s.mongoClient.Database("testcase_community").Collection("privacy_budgets").UpdateOne(ctx,
+		bson.M{"dataset": "budget-c"}, bson.M{"$set": bson.M{"renewed_at": nowInMillisecond() - time.Hour.Milliseconds()}})
+	s.NoError(err)
+	s.NoError(community.SpendPrivacyBudget(ctx, "budget-c", 1, 1, time.Hour))
+	s.Equal(ErrPrivacyBudgetExhausted, community.SpendPrivacyBudget(ctx, "budget-c", 1, 1, time.Hour))
+}
+
+func TestPrivacyBudget(t *testing.T) {
+	suite.Run(t, NewPrivacyBudgetTestSuite("mongodb://127.0.0.1:27017/?compressors=disabled"))
+}
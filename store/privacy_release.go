@@ -0,0 +1,90 @@
+package store
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func init() {
+	// releases are noised statistics of many accounts and are not linked to any account
+	CommunityResources.Register(Resource{
+		Name:       "privacy_releases",
+		Collection: "privacy_releases",
+		Indexes: []mongo.IndexModel{
+			{
+				Keys: bson.D{
+					{"dataset", 1},
+					{"key", 1},
+				},
+				Options: options.Index().SetUnique(true).SetName("dataset_key_unique"),
+			},
+		},
+		Delete: RetainAccountData,
+	})
+}
+
+// PrivacyRelease is a noised statistic of a dataset. It is released once for each version of the
+// data it is computed from, and reused until the data changes, so that repeated queries do not
+// spend the privacy budget again.
+type PrivacyRelease struct {
+	Dataset string `bson:"dataset"`
+	// Key identifies the statistic in the dataset
+	Key string `bson:"key"`
+	// Version identifies the data the statistic is computed from
+	Version   string   `bson:"version"`
+	Value     bson.Raw `bson:"value"`
+	Timestamp int64    `bson:"timestamp"`
+}
+
+// NewPrivacyRelease returns a release with the noised value encoded
+func NewPrivacyRelease(dataset, key, version string, value interface{}) (PrivacyRelease, error) {
+	data, err := bson.Marshal(value)
+	if err != nil {
+		return PrivacyRelease{}, err
+	}
+
+	return PrivacyRelease{
+		Dataset: dataset,
+		Key:     key,
+		Version: version,
+		Value:   data,
+	}, nil
+}
+
+// DecodeValue decodes the noised value of the release into v
+func (r PrivacyRelease) DecodeValue(v interface{}) error {
+	return bson.Unmarshal(r.Value, v)
+}
+
+// GetPrivacyReleases returns the latest releases of statistics of a dataset by their keys
+func (m *mongoCommunityStore) GetPrivacyReleases(ctx context.Context, dataset string, keys []string) (map[string]PrivacyRelease, error) {
+	releases := map[string]PrivacyRelease{}
+	if len(keys) == 0 {
+		return releases, nil
+	}
+
+	cursor, err := m.Resource("privacy_releases").Find(ctx, bson.M{"dataset": dataset, "key": bson.M{"$in": keys}})
+	if err != nil {
+		return nil, err
+	}
+
+	var results []PrivacyRelease
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	for _, r := range results {
+		releases[r.Key] = r
+	}
+	return releases, nil
+}
+
+// SetPrivacyRelease replaces the release of a statistic
+func (m *mongoCommunityStore) SetPrivacyRelease(ctx context.Context, release PrivacyRelease) error {
+	release.Timestamp = nowInMillisecond()
+	_, err := m.Resource("privacy_releases").ReplaceOne(ctx,
+		bson.M{"dataset": release.Dataset, "key": release.Key}, release, options.Replace().SetUpsert(true))
+	return err
+}
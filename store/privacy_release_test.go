@@ -0,0 +1,92 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type PrivacyReleaseTestSuite struct {
+	suite.Suite
+	connURI     string
+	mongoClient *mongo.Client
+}
+
+func NewPrivacyReleaseTestSuite(connURI string) *PrivacyReleaseTestSuite {
+	return &PrivacyReleaseTestSuite{
+		connURI: connURI,
+	}
+}
+
+func (s *PrivacyReleaseTestSuite) SetupSuite() {
+	if s.connURI == "" {
+		s.T().Fatal("invalid test suite configuration")
+	}
+
+	opts := options.Client().ApplyURI(s.connURI)
+	mongoClient, err := mongo.NewClient(opts)
+	if nil != err {
+		s.T().Fatalf("create mongo client with error: %s", err)
+	}
+
+	ctx := context.Background()
+	if err = mongoClient.Connect(ctx); nil != err {
+		s.T().Fatalf("connect mongo database with error: %s", err.Error())
+	}
+
+	s.mongoClient = mongoClient
+
+	dbNames, err := mongoClient.ListDatabaseNames(ctx, bson.M{"name": primitive.Regex{Pattern: "^testcase_"}})
+	if err != nil {
+		s.T().Fatalf("list all databases with error: %s", err.Error())
+	}
+
+	for _, name := range dbNames {
+		if err := mongoClient.Database(name).Drop(ctx); err != nil {
+			s.T().Fatalf("drop database with error: %s", err.Error())
+		}
+	}
+
+	if err := newTestDataPool(s.mongoClient).InitCommunityStore(); err != nil {
+		s.T().Fatalf("init community store with error: %s", err.Error())
+	}
+}
+
+func (s *PrivacyReleaseTestSuite) TestPrivacyRelease() {
+	ctx := context.Background()
+	community := newTestDataPool(s.mongoClient).Community()
+
+	releases, err := community.GetPrivacyReleases(ctx, "poi_ratings", []string{"poi-1"})
+	s.NoError(err)
+	s.Empty(releases)
+
+	release, err := NewPrivacyRelease("poi_ratings", "poi-1", "v1", POISummarizedRating{ID: "poi-1", AverageRating: 3.5})
+	s.NoError(err)
+	s.NoError(community.SetPrivacyRelease(ctx, release))
+	release, err = NewPrivacyRelease("poi_ratings", "poi-1", "v2", POISummarizedRating{ID: "poi-1", AverageRating: 4})
+	s.NoError(err)
+	s.NoError(community.SetPrivacyRelease(ctx, release))
+	// releases of datasets are independent
+	release, err = NewPrivacyRelease("symptom_reports", "poi-1", "v1", SymptomDailyReport{})
+	s.NoError(err)
+	s.NoError(community.SetPrivacyRelease(ctx, release))
+
+	releases, err = community.GetPrivacyReleases(ctx, "poi_ratings", []string{"poi-1", "poi-2"})
+	s.NoError(err)
+	s.Len(releases, 1)
+	s.Equal("v2", releases["poi-1"].Version)
+	s.NotZero(releases["poi-1"].Timestamp)
+
+	var summary POISummarizedRating
+	s.NoError(releases["poi-1"].DecodeValue(&summary))
+	s.Equal(4.0, summary.AverageRating)
+}
+
+func TestPrivacyRelease(t *testing.T) {
+	suite.Run(t, NewPrivacyReleaseTestSuite("mongodb://127.0.0.1:27017/?compressors=disabled"))
+}
@@ -0,0 +1,107 @@
+package store
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// legacyOwnerKey is the field which held raw account numbers in the community store
+// before they are replaced by pseudonyms
+const legacyOwnerKey = "account_number"
+
+// pseudonymize derives the pseudonym of an account number with a keyed HMAC. The pseudonym
+// is stable for an account, so its data can be located again, but can not be linked back
+// to the account without the key.
+func pseudonymize(key []byte, accountNumber string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(accountNumber))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SetPseudonymKey sets the secret key used to derive account pseudonyms in the community store
+func (m *mongodbDataPool) SetPseudonymKey(key []byte) {
+	m.pseudonymKey = key
+}
+
+// accountPseudonym returns the identifier of an account in the community store
+func (m mongoCommunityStore) accountPseudonym(accountNumber string) string {
+	return pseudonymize(m.pseudonymKey, accountNumber)
+}
+
+// migrateAccountPseudonyms replaces raw account numbers in the community store with their
+// pseudonyms. Indexes on raw account numbers are dropped first since they would conflict
+// with documents being migrated.
+func migrateAccountPseudonyms(ctx context.Context, db *mongo.Database, key []byte) error {
+	for _, resource := range CommunityResources.Resources() {
+		if resource.OwnerKey == "" {
+			continue
+		}
+
+		collection := db.Collection(resource.Collection)
+		if err := dropLegacyOwnerIndexes(ctx, collection); err != nil {
+			return err
+		}
+
+		accountNumbers, err := collection.Distinct(ctx, legacyOwnerKey, bson.M{legacyOwnerKey: bson.M{"$exists": true}})
+		if err != nil {
+			return err
+		}
+
+		for _, a := range accountNumbers {
+			accountNumber, ok := a.(string)
+			if !ok {
+				return fmt.Errorf("invalid account number %v in %s", a, resource.Collection)
+			}
+
+			result, err := collection.UpdateMany(ctx,
+				bson.M{legacyOwnerKey: accountNumber},
+				bson.M{
+					"$set":   bson.M{resource.OwnerKey: pseudonymize(key, accountNumber)},
+					"$unset": bson.M{legacyOwnerKey: ""},
+				})
+			if err != nil {
+				return err
+			}
+
+			log.WithField("prefix", mongoLogPrefix).WithField("collection", resource.Collection).
+				WithField("count", result.ModifiedCount).Debug("pseudonymize account documents")
+		}
+	}
+
+	return nil
+}
+
+// dropLegacyOwnerIndexes drops indexes which contain the raw account number field
+func dropLegacyOwnerIndexes(ctx context.Context, collection *mongo.Collection) error {
+	cursor, err := collection.Indexes().List(ctx)
+	if err != nil {
+		return err
+	}
+
+	var indexes []struct {
+		Name string `bson:"name"`
+		Key  bson.M `bson:"key"`
+	}
+	if err := cursor.All(ctx, &indexes); err != nil {
+		return err
+	}
+
+	for _, index := range indexes {
+		if _, ok := index.Key[legacyOwnerKey]; !ok {
+			continue
+		}
+
+		if _, err := collection.Indexes().DropOne(ctx, index.Name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
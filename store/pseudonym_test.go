@@ -0,0 +1,116 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestPseudonymize(t *testing.T) {
+	p := pseudonymize([]byte("key"), "account")
+	assert.Len(t, p, 64)
+	assert.Equal(t, p, pseudonymize([]byte("key"), "account"))
+	assert.NotEqual(t, p, pseudonymize([]byte("key"), "another account"))
+	assert.NotEqual(t, p, pseudonymize([]byte("another key"), "account"))
+}
+
+type PseudonymMigrationTestSuite struct {
+	suite.Suite
+	connURI     string
+	mongoClient *mongo.Client
+}
+
+func NewPseudonymMigrationTestSuite(connURI string) *PseudonymMigrationTestSuite {
+	return &PseudonymMigrationTestSuite{
+		connURI: connURI,
+	}
+}
+
+func (s *PseudonymMigrationTestSuite) SetupSuite() {
+	if s.connURI == "" {
+		s.T().Fatal("invalid test suite configuration")
+	}
+
+	opts := options.Client().ApplyURI(s.connURI)
+	mongoClient, err := mongo.NewClient(opts)
+	if nil != err {
+		s.T().Fatalf("create mongo client with error: %s", err)
+	}
+
+	ctx := context.Background()
+	if err = mongoClient.Connect(ctx); nil != err {
+		s.T().Fatalf("connect mongo database with error: %s", err.Error())
+	}
+
+	s.mongoClient = mongoClient
+
+	dbNames, err := mongoClient.ListDatabaseNames(ctx, bson.M{"name": primitive.Regex{Pattern: "^testcase_"}})
+	if err != nil {
+		s.T().Fatalf("list all databases with error: %s", err.Error())
+	}
+
+	for _, name := range dbNames {
+		if err := mongoClient.Database(name).Drop(ctx); err != nil {
+			s.T().Fatalf("drop database with error: %s", err.Error())
+		}
+	}
+
+	// a community store with raw account numbers and the index on them
+	ratings := mongoClient.Database(TestDBPrefix + "community").Collection("poi_ratings")
+	if _, err := ratings.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{"account_number", 1}, {"id", 1}},
+		Options: options.Index().SetUnique(true).SetName("id_account_unique"),
+	}); err != nil {
+		s.T().Fatalf("create legacy index with error: %s", err.Error())
+	}
+
+	if _, err := ratings.InsertMany(ctx, []interface{}{
+		bson.M{"id": "poi-1", "account_number": "user1", "ratings": bson.M{"mask": 5}, "timestamp": 1},
+		bson.M{"id": "poi-2", "account_number": "user1", "ratings": bson.M{"mask": 3}, "timestamp": 2},
+		bson.M{"id": "poi-1", "account_number": "user2", "ratings": bson.M{"mask": 4}, "timestamp": 3},
+	}); err != nil {
+		s.T().Fatalf("insert legacy ratings with error: %s", err.Error())
+	}
+}
+
+func (s *PseudonymMigrationTestSuite) TestMigrate() {
+	ctx := context.Background()
+	pool := newTestDataPool(s.mongoClient)
+
+	// migrating twice must not change anything
+	s.NoError(pool.InitCommunityStore())
+	s.NoError(pool.InitCommunityStore())
+
+	for _, collection := range []string{"poi_ratings", "poi_rating_history"} {
+		c := s.mongoClient.Database(TestDBPrefix + "community").Collection(collection)
+
+		count, err := c.CountDocuments(ctx, bson.M{"account_number": bson.M{"$exists": true}})
+		s.NoError(err)
+		s.Equal(int64(0), count)
+
+		count, err = c.CountDocuments(ctx, bson.M{"account_pseudonym": pseudonymize(testPseudonymKey, "user1")})
+		s.NoError(err)
+		s.Equal(int64(2), count)
+	}
+
+	// ratings are located by the pseudonym after the migration
+	s.NoError(pool.Community().SetPOIRating(ctx, "user2", "poi-1", map[string]float64{"mask": 1}))
+	count, err := s.mongoClient.Database(TestDBPrefix+"community").Collection("poi_ratings").CountDocuments(ctx, bson.M{"id": "poi-1"})
+	s.NoError(err)
+	s.Equal(int64(2), count)
+
+	s.NoError(pool.Community().DeleteData(ctx, "user1"))
+	count, err = s.mongoClient.Database(TestDBPrefix+"community").Collection("poi_ratings").CountDocuments(ctx, bson.M{})
+	s.NoError(err)
+	s.Equal(int64(1), count)
+}
+
+func TestPseudonymMigration(t *testing.T) {
+	suite.Run(t, NewPseudonymMigrationTestSuite("mongodb://127.0.0.1:27017/?compressors=disabled"))
+}
@@ -4,43 +4,46 @@ import (
 	"context"
 	"fmt"
 
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 func indexForPersonalAccountStore(db *mongo.Database) error {
-	_, err := db.Collection("poi_ratings").Indexes().CreateMany(context.Background(), []mongo.IndexModel{
-		{
-			Keys: bson.D{
-				{"id", 1},
-			},
-			Options: options.Index().SetUnique(true).SetName("id_unique"),
-		},
-	})
-	return err
+	return PersonalResources.createIndexes(context.Background(), db)
 }
 
 func indexForCommunityStore(db *mongo.Database) error {
-	_, err := db.Collection("poi_ratings").Indexes().CreateMany(context.Background(), []mongo.IndexModel{
-		{
-			Keys: bson.D{
-				{"account_number", 1},
-				{"id", 1},
-			},
-			Options: options.Index().SetUnique(true).SetName("id_account_unique"),
-		},
-	})
-	return err
+	return CommunityResources.createIndexes(context.Background(), db)
 }
 
-// Account returns a personal data store.
+// RegisterAccount initializes the personal data store of an account.
 func (m mongodbDataPool) RegisterAccount(accountNumber string) error {
 	dbName := fmt.Sprintf("%s%s", m.dbPrefix, accountNumber)
 	return indexForPersonalAccountStore(m.client.Database(dbName))
 }
 
+// InitCommunityStore migrates the community store and creates its indexes.
 func (m mongodbDataPool) InitCommunityStore() error {
+	if len(m.pseudonymKey) == 0 {
+		return fmt.Errorf("pseudonym key is required")
+	}
+	if m.defaultCohort == "" {
+		return fmt.Errorf("default cohort is required")
+	}
+
+	ctx := context.Background()
 	dbName := fmt.Sprintf("%scommunity", m.dbPrefix)
-	return indexForCommunityStore(m.client.Database(dbName))
+	db := m.client.Database(dbName)
+	if err := migrateAccountPseudonyms(ctx, db, m.pseudonymKey); err != nil {
+		return err
+	}
+
+	if err := migrateSymptomReportCohorts(ctx, db, m.defaultCohort); err != nil {
+		return err
+	}
+
+	if err := indexForCommunityStore(db); err != nil {
+		return err
+	}
+
+	return backfillPOIRatingHistory(ctx, db)
 }
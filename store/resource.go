@@ -0,0 +1,102 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// DeletePolicy determines what happens to a resource when an account asks to delete its data
+type DeletePolicy int
+
+const (
+	// DeleteAccountData removes every document that belongs to the account
+	DeleteAccountData DeletePolicy = iota
+	// RetainAccountData keeps the documents, e.g. for data that can not be linked to an account
+	RetainAccountData
+)
+
+// ExportFunc serializes the documents of a resource into an exported file
+type ExportFunc func(ctx context.Context, cursor *mongo.Cursor, w io.Writer) error
+
+// Resource declares a kind of data kept by a data store. It describes where the data
+// is stored and how the data is treated when an account exports or deletes its data.
+type Resource struct {
+	// Name is used as the name of the exported file
+	Name string
+	// Collection is the collection the documents are stored in
+	Collection string
+	// Indexes are created when a store is initialized
+	Indexes []mongo.IndexModel
+	// OwnerKey is the field which holds the account pseudonym of a document. It is
+	// only required for resources in the community store.
+	OwnerKey string
+	// Export serializes the documents of an account. Nil means not exported.
+	Export ExportFunc
+	// Delete is the policy applied when an account deletes its data
+	Delete DeletePolicy
+}
+
+// ResourceRegistry keeps resources in the order they are registered
+type ResourceRegistry struct {
+	resources []Resource
+	names     map[string]struct{}
+}
+
+var (
+	// PersonalResources are resources kept in personal data stores
+	PersonalResources = &ResourceRegistry{}
+	// CommunityResources are resources kept in the community data store
+	CommunityResources = &ResourceRegistry{}
+)
+
+// Register adds a resource into the registry. It panics if a resource with the same
+// name has been registered, since resources are expected to be registered on init.
+func (r *ResourceRegistry) Register(resource Resource) {
+	if resource.Name == "" || resource.Collection == "" {
+		panic("resource name and collection are required")
+	}
+
+	if r.names == nil {
+		r.names = map[string]struct{}{}
+	}
+
+	if _, ok := r.names[resource.Name]; ok {
+		panic(fmt.Sprintf("resource %s is registered twice", resource.Name))
+	}
+
+	r.names[resource.Name] = struct{}{}
+	r.resources = append(r.resources, resource)
+}
+
+// Resources returns all registered resources
+func (r *ResourceRegistry) Resources() []Resource {
+	return r.resources
+}
+
+// createIndexes creates indexes of all registered resources in the given database
+func (r *ResourceRegistry) createIndexes(ctx context.Context, db *mongo.Database) error {
+	for _, resource := range r.resources {
+		if len(resource.Indexes) == 0 {
+			continue
+		}
+
+		if _, err := db.Collection(resource.Collection).Indexes().CreateMany(ctx, resource.Indexes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExportJSON encodes all documents as a JSON array
+func ExportJSON(ctx context.Context, cursor *mongo.Cursor, w io.Writer) error {
+	data := []interface{}{}
+	if err := cursor.All(ctx, &data); err != nil {
+		return err
+	}
+
+	return json.NewEncoder(w).Encode(data)
+}
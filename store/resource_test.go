@@ -0,0 +1,33 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResourceRegistry(t *testing.T) {
+	registry := &ResourceRegistry{}
+	registry.Register(Resource{Name: "a", Collection: "a"})
+	registry.Register(Resource{Name: "b", Collection: "b", OwnerKey: "account_number"})
+
+	resources := registry.Resources()
+	assert.Len(t, resources, 2)
+	assert.Equal(t, "a", resources[0].Name)
+	assert.Equal(t, "b", resources[1].Name)
+
+	assert.Panics(t, func() {
+		registry.Register(Resource{Name: "a", Collection: "c"})
+	})
+	assert.Panics(t, func() {
+		registry.Register(Resource{Name: "d"})
+	})
+}
+
+func TestRegisteredResources(t *testing.T) {
+	for _, resource := range CommunityResources.Resources() {
+		if resource.Export != nil || resource.Delete == DeleteAccountData {
+			assert.NotEmpty(t, resource.OwnerKey, "community resource %s needs an owner key", resource.Name)
+		}
+	}
+}
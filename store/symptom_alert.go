@@ -0,0 +1,85 @@
+package store
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func init() {
+	// symptom alerts are detected from daily reports and are not linked to any account
+	CommunityResources.Register(Resource{
+		Name:       "symptom_alerts",
+		Collection: "symptom_alerts",
+		Indexes: []mongo.IndexModel{
+			{
+				Keys: bson.D{
+					{"cohort", 1},
+					{"symptom", 1},
+					{"date", 1},
+				},
+				Options: options.Index().SetUnique(true).SetName("cohort_symptom_date_unique"),
+			},
+			{
+				Keys: bson.D{
+					{"cohort", 1},
+					{"date", -1},
+				},
+				Options: options.Index().SetName("cohort_date"),
+			},
+		},
+		Delete: RetainAccountData,
+	})
+}
+
+// SymptomAlert is a spike of the daily count of a symptom of a cohort compared with its baseline
+type SymptomAlert struct {
+	Cohort  string  `bson:"cohort" json:"cohort"`
+	Symptom string  `bson:"symptom" json:"symptom"`
+	Date    string  `bson:"date" json:"date"`
+	Value   int     `bson:"value" json:"value"`
+	Mean    float64 `bson:"mean" json:"mean"`
+	StdDev  float64 `bson:"std_dev" json:"std_dev"`
+	ZScore  float64 `bson:"z_score" json:"z_score"`
+	// BaselineDays is the number of reported days the mean and standard deviation are computed from
+	BaselineDays int   `bson:"baseline_days" json:"baseline_days"`
+	CreatedAt    int64 `bson:"created_at" json:"created_at"`
+}
+
+// AddSymptomAlerts keeps alerts which are not detected before, and returns them.
+// An alert of a symptom of a cohort is raised at most once for a date.
+func (m *mongoCommunityStore) AddSymptomAlerts(ctx context.Context, alerts []SymptomAlert) ([]SymptomAlert, error) {
+	added := make([]SymptomAlert, 0)
+	for _, alert := range alerts {
+		alert.CreatedAt = nowInMillisecond()
+		result, err := m.Resource("symptom_alerts").UpdateOne(ctx,
+			bson.M{"cohort": alert.Cohort, "symptom": alert.Symptom, "date": alert.Date},
+			bson.M{"$setOnInsert": alert},
+			options.Update().SetUpsert(true))
+		if err != nil {
+			return nil, err
+		}
+		if result.UpsertedCount > 0 {
+			added = append(added, alert)
+		}
+	}
+	return added, nil
+}
+
+// GetSymptomAlerts returns alerts of a cohort of dates since `since`, latest first
+func (m *mongoCommunityStore) GetSymptomAlerts(ctx context.Context, cohort, since string) ([]SymptomAlert, error) {
+	cursor, err := m.Resource("symptom_alerts").Find(ctx,
+		bson.M{"cohort": cohort, "date": bson.M{"$gte": since}},
+		options.Find().SetSort(bson.D{{"date", -1}, {"z_score", -1}}))
+	if err != nil {
+		return nil, err
+	}
+
+	alerts := []SymptomAlert{}
+	if err := cursor.All(ctx, &alerts); err != nil {
+		return nil, err
+	}
+	return alerts, nil
+}
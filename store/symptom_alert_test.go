@@ -0,0 +1,96 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SymptomAlertTestSuite struct {
+	suite.Suite
+	connURI     string
+	mongoClient *mongo.Client
+}
+
+func NewSymptomAlertTestSuite(connURI string) *SymptomAlertTestSuite {
+	return &SymptomAlertTestSuite{
+		connURI: connURI,
+	}
+}
+
+func (s *SymptomAlertTestSuite) SetupSuite() {
+	if s.connURI == "" {
+		s.T().Fatal("invalid test suite configuration")
+	}
+
+	opts := options.Client().ApplyURI(s.connURI)
+	mongoClient, err := mongo.NewClient(opts)
+	if nil != err {
+		s.T().Fatalf("create mongo client with error: %s", err)
+	}
+
+	ctx := context.Background()
+	if err = mongoClient.Connect(ctx); nil != err {
+		s.T().Fatalf("connect mongo database with error: %s", err.Error())
+	}
+
+	s.mongoClient = mongoClient
+
+	dbNames, err := mongoClient.ListDatabaseNames(ctx, bson.M{"name": primitive.Regex{Pattern: "^testcase_"}})
+	if err != nil {
+		s.T().Fatalf("list all databases with error: %s", err.Error())
+	}
+
+	for _, name := range dbNames {
+		if err := mongoClient.Database(name).Drop(ctx); err != nil {
+			s.T().Fatalf("drop database with error: %s", err.Error())
+		}
+	}
+
+	if err := newTestDataPool(s.mongoClient).InitCommunityStore(); err != nil {
+		s.T().Fatalf("init community store with error: %s", err.Error())
+	}
+}
+
+func (s *SymptomAlertTestSuite) TestSymptomAlerts() {
+	ctx := context.Background()
+	store := newTestDataPool(s.mongoClient).Community()
+
+	added, err := store.AddSymptomAlerts(ctx, []SymptomAlert{
+		{Cohort: testDefaultCohort, Symptom: "Cough", Date: "2020-07-20", Value: 20, ZScore: 3},
+		{Cohort: testDefaultCohort, Symptom: "Cough", Date: "2020-07-21", Value: 30, ZScore: 4},
+		{Cohort: testDefaultCohort, Symptom: "Fever", Date: "2020-07-21", Value: 10, ZScore: 5},
+	})
+	s.NoError(err)
+	s.Len(added, 3)
+
+	// alerts are raised once for a symptom and a date
+	added, err = store.AddSymptomAlerts(ctx, []SymptomAlert{
+		{Cohort: testDefaultCohort, Symptom: "Cough", Date: "2020-07-21", Value: 31, ZScore: 4.5},
+	})
+	s.NoError(err)
+	s.Empty(added)
+
+	// the same symptom can be alerted in another cohort
+	added, err = store.AddSymptomAlerts(ctx, []SymptomAlert{
+		{Cohort: "testcase_other_cohort", Symptom: "Cough", Date: "2020-07-21", Value: 12, ZScore: 3},
+	})
+	s.NoError(err)
+	s.Len(added, 1)
+
+	alerts, err := store.GetSymptomAlerts(ctx, testDefaultCohort, "2020-07-21")
+	s.NoError(err)
+	s.Len(alerts, 2)
+	s.Equal("Fever", alerts[0].Symptom)
+	s.Equal("Cough", alerts[1].Symptom)
+	s.Equal(30, alerts[1].Value)
+}
+
+func TestSymptomAlert(t *testing.T) {
+	suite.Run(t, NewSymptomAlertTestSuite("mongodb://127.0.0.1:27017/?compressors=disabled"))
+}
@@ -0,0 +1,204 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SymptomCheckinDateLayout is the layout of dates of symptom check-ins and daily reports
+const SymptomCheckinDateLayout = "2006-01-02"
+
+// checkinWindowDays is the number of days counted by CheckinsNumPastThreeDays
+const checkinWindowDays = 3
+
+// NormalizeSymptoms trims symptom ids, removes empty and duplicated ones, and sorts them
+func NormalizeSymptoms(symptoms []string) []string {
+	seen := make(map[string]struct{}, len(symptoms))
+	results := make([]string, 0, len(symptoms))
+	for _, s := range symptoms {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		if _, ok := seen[s]; ok {
+			continue
+		}
+		seen[s] = struct{}{}
+		results = append(results, s)
+	}
+	sort.Strings(results)
+	return results
+}
+
+func init() {
+	PersonalResources.Register(Resource{
+		Name:       "symptom_checkins",
+		Collection: "symptom_checkins",
+		Indexes: []mongo.IndexModel{
+			{
+				Keys: bson.D{
+					{"date", 1},
+				},
+				Options: options.Index().SetUnique(true).SetName("date_unique"),
+			},
+		},
+		Export: ExportJSON,
+		Delete: DeleteAccountData,
+	})
+
+	CommunityResources.Register(Resource{
+		Name:       "symptom_checkins",
+		Collection: "symptom_checkins",
+		Indexes: []mongo.IndexModel{
+			{
+				Keys: bson.D{
+					{"account_pseudonym", 1},
+					{"date", 1},
+				},
+				Options: options.Index().SetUnique(true).SetName("account_pseudonym_date_unique"),
+			},
+			{
+				Keys: bson.D{
+					{"date", 1},
+				},
+				Options: options.Index().SetName("date"),
+			},
+		},
+		OwnerKey: "account_pseudonym",
+		Export:   ExportJSON,
+		Delete:   DeleteAccountData,
+	})
+}
+
+// SymptomCheckin is the symptoms an account has on a day. An empty list of symptoms
+// means the account checks in without any symptom.
+type SymptomCheckin struct {
+	Date      string   `bson:"date" json:"date"`
+	Symptoms  []string `bson:"symptoms" json:"symptoms"`
+	Timestamp int64    `bson:"timestamp,omitempty" json:"timestamp,omitempty"`
+}
+
+// SetSymptomCheckin creates or replaces the check-in of a day
+func (m *mongoAccountStore) SetSymptomCheckin(ctx context.Context, checkin SymptomCheckin) error {
+	_, err := m.Resource("symptom_checkins").UpdateOne(ctx,
+		bson.M{"date": checkin.Date},
+		bson.M{
+			"$set":         bson.M{"symptoms": checkin.Symptoms, "timestamp": nowInMillisecond()},
+			"$setOnInsert": bson.M{"date": checkin.Date},
+		},
+		options.Update().SetUpsert(true))
+	return err
+}
+
+// GetSymptomCheckins returns check-ins from the date on, latest first
+func (m *mongoAccountStore) GetSymptomCheckins(ctx context.Context, since string) ([]SymptomCheckin, error) {
+	cursor, err := m.Resource("symptom_checkins").Find(ctx,
+		bson.M{"date": bson.M{"$gte": since}},
+		options.Find().SetSort(bson.D{{"date", -1}}))
+	if err != nil {
+		return nil, err
+	}
+
+	checkins := []SymptomCheckin{}
+	if err := cursor.All(ctx, &checkins); err != nil {
+		return nil, err
+	}
+
+	return checkins, nil
+}
+
+// SetSymptomCheckin creates or replaces the check-in of a day contributed by an account.
+// Only the date and the symptoms are kept, without the time of the check-in.
+func (m *mongoCommunityStore) SetSymptomCheckin(ctx context.Context, accountNumber string, checkin SymptomCheckin) error {
+	pseudonym := m.accountPseudonym(accountNumber)
+	_, err := m.Resource("symptom_checkins").UpdateOne(ctx,
+		bson.M{"account_pseudonym": pseudonym, "date": checkin.Date},
+		bson.M{
+			"$set":         bson.M{"symptoms": checkin.Symptoms},
+			"$setOnInsert": bson.M{"account_pseudonym": pseudonym, "date": checkin.Date},
+		},
+		options.Update().SetUpsert(true))
+	return err
+}
+
+// AggregateSymptomCheckins summarizes check-ins into daily reports of the dates from start to end.
+// Symptoms are counted by check-ins of the date, while CheckinsNumPastThreeDays counts accounts
+// which have checked in within the three days ending at the date. Dates without any check-in
+// in the three days are not reported.
+func (m *mongoCommunityStore) AggregateSymptomCheckins(ctx context.Context, start, end string) ([]SymptomDailyReport, error) {
+	startDate, err := time.Parse(SymptomCheckinDateLayout, start)
+	if err != nil {
+		return nil, err
+	}
+	windowStart := startDate.AddDate(0, 0, 1-checkinWindowDays).Format(SymptomCheckinDateLayout)
+
+	cursor, err := m.Resource("symptom_checkins").Find(ctx,
+		bson.M{"date": bson.M{"$gte": windowStart, "$lte": end}},
+		options.Find().SetProjection(bson.M{"_id": 0, "account_pseudonym": 1, "date": 1, "symptoms": 1}))
+	if err != nil {
+		return nil, err
+	}
+
+	var checkins []struct {
+		Account  string   `bson:"account_pseudonym"`
+		Date     string   `bson:"date"`
+		Symptoms []string `bson:"symptoms"`
+	}
+	if err := cursor.All(ctx, &checkins); err != nil {
+		return nil, err
+	}
+
+	symptomCounts := map[string]map[string]int{}
+	accounts := map[string]map[string]struct{}{}
+	for _, checkin := range checkins {
+		date, err := time.Parse(SymptomCheckinDateLayout, checkin.Date)
+		if err != nil {
+			return nil, err
+		}
+
+		// a check-in is counted by the report of its date and the following days in the window
+		for i := 0; i < checkinWindowDays; i++ {
+			d := date.AddDate(0, 0, i).Format(SymptomCheckinDateLayout)
+			if accounts[d] == nil {
+				accounts[d] = map[string]struct{}{}
+			}
+			accounts[d][checkin.Account] = struct{}{}
+		}
+
+		if symptomCounts[checkin.Date] == nil {
+			symptomCounts[checkin.Date] = map[string]int{}
+		}
+		for _, symptom := range checkin.Symptoms {
+			symptomCounts[checkin.Date][symptom]++
+		}
+	}
+
+	reports := []SymptomDailyReport{}
+	for date := startDate; date.Format(SymptomCheckinDateLayout) <= end; date = date.AddDate(0, 0, 1) {
+		d := date.Format(SymptomCheckinDateLayout)
+		if len(accounts[d]) == 0 {
+			continue
+		}
+
+		report := SymptomDailyReport{
+			Date:                     d,
+			Symptoms:                 []SymptomStats{},
+			CheckinsNumPastThreeDays: len(accounts[d]),
+		}
+		for name, count := range symptomCounts[d] {
+			report.Symptoms = append(report.Symptoms, SymptomStats{Name: name, Count: count})
+		}
+		sort.Slice(report.Symptoms, func(i, j int) bool {
+			return report.Symptoms[i].Name < report.Symptoms[j].Name
+		})
+		reports = append(reports, report)
+	}
+
+	return reports, nil
+}
@@ -0,0 +1,131 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SymptomCheckinTestSuite struct {
+	suite.Suite
+	connURI     string
+	mongoClient *mongo.Client
+}
+
+func NewSymptomCheckinTestSuite(connURI string) *SymptomCheckinTestSuite {
+	return &SymptomCheckinTestSuite{
+		connURI: connURI,
+	}
+}
+
+func (s *SymptomCheckinTestSuite) SetupSuite() {
+	if s.connURI == "" {
+		s.T().Fatal("invalid test suite configuration")
+	}
+
+	opts := options.Client().ApplyURI(s.connURI)
+	mongoClient, err := mongo.NewClient(opts)
+	if nil != err {
+		s.T().Fatalf("create mongo client with error: %s", err)
+	}
+
+	ctx := context.Background()
+	if err = mongoClient.Connect(ctx); nil != err {
+		s.T().Fatalf("connect mongo database with error: %s", err.Error())
+	}
+
+	s.mongoClient = mongoClient
+
+	dbNames, err := mongoClient.ListDatabaseNames(ctx, bson.M{"name": primitive.Regex{Pattern: "^testcase_"}})
+	if err != nil {
+		s.T().Fatalf("list all databases with error: %s", err.Error())
+	}
+
+	for _, name := range dbNames {
+		if err := mongoClient.Database(name).Drop(ctx); err != nil {
+			s.T().Fatalf("drop database with error: %s", err.Error())
+		}
+	}
+
+	if err := newTestDataPool(s.mongoClient).InitCommunityStore(); err != nil {
+		s.T().Fatalf("init community store with error: %s", err.Error())
+	}
+}
+
+func (s *SymptomCheckinTestSuite) TestPersonalSymptomCheckins() {
+	ctx := context.Background()
+	store := newTestDataPool(s.mongoClient).Account("testcase_checkin_account")
+
+	s.NoError(store.SetSymptomCheckin(ctx, SymptomCheckin{Date: "2020-07-20", Symptoms: []string{"cough"}}))
+	s.NoError(store.SetSymptomCheckin(ctx, SymptomCheckin{Date: "2020-07-21", Symptoms: []string{"fever"}}))
+	s.NoError(store.SetSymptomCheckin(ctx, SymptomCheckin{Date: "2020-07-21", Symptoms: []string{}}))
+
+	checkins, err := store.GetSymptomCheckins(ctx, "2020-07-20")
+	s.NoError(err)
+	s.Len(checkins, 2)
+	s.Equal("2020-07-21", checkins[0].Date)
+	s.Empty(checkins[0].Symptoms)
+	s.Equal([]string{"cough"}, checkins[1].Symptoms)
+
+	checkins, err = store.GetSymptomCheckins(ctx, "2020-07-21")
+	s.NoError(err)
+	s.Len(checkins, 1)
+}
+
+func (s *SymptomCheckinTestSuite) TestAggregateSymptomCheckins() {
+	ctx := context.Background()
+	community := newTestDataPool(s.mongoClient).Community()
+
+	for _, c := range []struct {
+		account string
+		checkin SymptomCheckin
+	}{
+		{"user1", SymptomCheckin{Date: "2020-07-18", Symptoms: []string{"cough"}}},
+		{"user1", SymptomCheckin{Date: "2020-07-20", Symptoms: []string{"cough", "fever"}}},
+		{"user2", SymptomCheckin{Date: "2020-07-20", Symptoms: []string{"cough"}}},
+		{"user3", SymptomCheckin{Date: "2020-07-21", Symptoms: []string{}}},
+		{"user3", SymptomCheckin{Date: "2020-07-21", Symptoms: []string{"fatigue"}}},
+	} {
+		s.NoError(community.SetSymptomCheckin(ctx, c.account, c.checkin))
+	}
+
+	reports, err := community.AggregateSymptomCheckins(ctx, "2020-07-20", "2020-07-24")
+	s.NoError(err)
+	s.Equal([]SymptomDailyReport{
+		{
+			Date:                     "2020-07-20",
+			Symptoms:                 []SymptomStats{{Name: "cough", Count: 2}, {Name: "fever", Count: 1}},
+			CheckinsNumPastThreeDays: 2,
+		},
+		{
+			Date:                     "2020-07-21",
+			Symptoms:                 []SymptomStats{{Name: "fatigue", Count: 1}},
+			CheckinsNumPastThreeDays: 3,
+		},
+		{
+			Date:                     "2020-07-22",
+			Symptoms:                 []SymptomStats{},
+			CheckinsNumPastThreeDays: 3,
+		},
+		{
+			Date:                     "2020-07-23",
+			Symptoms:                 []SymptomStats{},
+			CheckinsNumPastThreeDays: 1,
+		},
+	}, reports)
+}
+
+func TestSymptomCheckin(t *testing.T) {
+	suite.Run(t, NewSymptomCheckinTestSuite("mongodb://127.0.0.1:27017/?compressors=disabled"))
+}
+
+func TestNormalizeSymptoms(t *testing.T) {
+	assert.Equal(t, []string{}, NormalizeSymptoms(nil))
+	assert.Equal(t, []string{"cough", "fever"}, NormalizeSymptoms([]string{" fever", "cough", "", "fever "}))
+}
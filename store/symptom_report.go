@@ -2,35 +2,60 @@ package store
 
 import (
 	"context"
+	"sort"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+func init() {
+	// symptom reports are prepared from anonymous statistics and are not linked to any account
+	CommunityResources.Register(Resource{
+		Name:       "symptom_reports",
+		Collection: "symptom_reports",
+		Indexes: []mongo.IndexModel{
+			{
+				Keys: bson.D{
+					{"cohort", 1},
+					{"date", 1},
+				},
+				Options: options.Index().SetUnique(true).SetName("cohort_date_unique"),
+			},
+		},
+		Delete: RetainAccountData,
+	})
+}
+
+// SymptomDailyReport is the report of a day of a cohort, e.g. a campus or a study
 type SymptomDailyReport struct {
-	Date                     string         `bson:"date"`
-	Symptoms                 []SymptomStats `bson:"symptoms"`
-	CheckinsNumPastThreeDays int            `bson:"checkins_num_past_three_days"`
+	Cohort                   string         `bson:"cohort" json:"cohort,omitempty"`
+	Date                     string         `bson:"date" json:"date"`
+	Symptoms                 []SymptomStats `bson:"symptoms" json:"symptoms"`
+	CheckinsNumPastThreeDays int            `bson:"checkins_num_past_three_days" json:"checkins_num_past_three_days"`
 }
 
 type SymptomStats struct {
-	Name  string `bson:"name"`
-	Count int    `bson:"count"`
+	Name  string `bson:"name" json:"name"`
+	Count int    `bson:"count" json:"count"`
 }
 
+// AddSymptomDailyReports upserts reports which are not uploaded in batches, e.g. the ones
+// aggregated from check-ins. Reports of dates owned by batches are kept, so that uploaded
+// reports are only replaced by later batches or restored by rollbacks.
 func (m *mongoCommunityStore) AddSymptomDailyReports(ctx context.Context, reports []SymptomDailyReport) error {
-	opts := options.Update().SetUpsert(true)
+	owned, err := m.batchOwnedReportDates(ctx, reports)
+	if err != nil {
+		return err
+	}
+
 	for _, report := range reports {
-		filter := bson.M{"date": report.Date}
-		update := bson.M{
-			"$set": bson.M{
-				"symptoms":                     report.Symptoms,
-				"checkins_num_past_three_days": report.CheckinsNumPastThreeDays,
-			},
+		if _, ok := owned[report.Cohort+"/"+report.Date]; ok {
+			continue
 		}
-		_, err := m.Resource("symptom_reports").UpdateOne(ctx, filter, update, opts)
-		if err != nil {
+
+		if err := m.replaceSymptomReport(ctx, report, nil); err != nil {
 			return err
 		}
 	}
@@ -38,6 +63,36 @@ func (m *mongoCommunityStore) AddSymptomDailyReports(ctx context.Context, report
 	return nil
 }
 
+// batchOwnedReportDates returns the cohorts and dates, joined as `<cohort>/<date>`, of the
+// reports whose current versions are uploaded in batches
+func (m *mongoCommunityStore) batchOwnedReportDates(ctx context.Context, reports []SymptomDailyReport) (map[string]struct{}, error) {
+	owned := map[string]struct{}{}
+	if len(reports) == 0 {
+		return owned, nil
+	}
+
+	filters := make(bson.A, 0, len(reports))
+	for _, report := range reports {
+		filters = append(filters, bson.M{"cohort": report.Cohort, "date": report.Date})
+	}
+
+	cursor, err := m.Resource("symptom_reports").Find(ctx,
+		bson.M{"$or": filters, "batch_id": bson.M{"$exists": true}},
+		options.Find().SetProjection(bson.M{"cohort": 1, "date": 1}))
+	if err != nil {
+		return nil, err
+	}
+
+	var current []SymptomDailyReport
+	if err := cursor.All(ctx, &current); err != nil {
+		return nil, err
+	}
+	for _, report := range current {
+		owned[report.Cohort+"/"+report.Date] = struct{}{}
+	}
+	return owned, nil
+}
+
 type BucketAggregation struct {
 	ID      string   `bson:"_id"`
 	Buckets []Bucket `bson:"buckets"`
@@ -48,47 +103,149 @@ type Bucket struct {
 	Value int    `bson:"value" json:"value"`
 }
 
-// GetSymptomReportItems returns report items in the date range which starts at `limit` days ago, and ends at `end`.
-func (m *mongoCommunityStore) GetSymptomReportItems(ctx context.Context, end string, limit int64) (map[string][]Bucket, error) {
-	pipeline := mongo.Pipeline{
-		AggregationMatch(bson.M{
-			"date": bson.M{
-				"$lte": end,
-			},
-		}),
-		AggregationSort("date", -1),
-		AggregationLimit(limit),
-		AggregationUnwind("$symptoms"),
-		AggregationGroup("$symptoms.name", bson.D{
-			bson.E{
-				Key: "buckets",
-				Value: bson.M{"$push": bson.M{
-					"name":  "$date",
-					"value": "$symptoms.count",
-				}},
-			},
-		}),
+// SymptomReportCoverage describes which days of a window have daily reports
+type SymptomReportCoverage struct {
+	Start        string   `json:"start"`
+	End          string   `json:"end"`
+	Days         int      `json:"days"`
+	ReportedDays int      `json:"reported_days"`
+	MissingDates []string `json:"missing_dates"`
+	Complete     bool     `json:"complete"`
+}
+
+// GetSymptomDailyReports returns the reports of a cohort from `start` to `end` inclusively, ordered by date
+func (m *mongoCommunityStore) GetSymptomDailyReports(ctx context.Context, cohort, start, end string) ([]SymptomDailyReport, error) {
+	cursor, err := m.Resource("symptom_reports").Find(ctx, bson.M{
+		"cohort": cohort,
+		"date": bson.M{
+			"$gte": start,
+			"$lte": end,
+		},
+	}, options.Find().SetSort(bson.D{{"date", 1}}))
+	if err != nil {
+		return nil, err
+	}
+
+	reports := []SymptomDailyReport{}
+	if err := cursor.All(ctx, &reports); err != nil {
+		return nil, err
 	}
-	cursor, err := m.Resource("symptom_reports").Aggregate(ctx, pipeline)
+	return reports, nil
+}
+
+// GetSymptomReportItems returns report items of a cohort of each day from `start` to `end` inclusively, latest first.
+// Days without a report, and symptoms not in the report of a day, are filled with zero values.
+func (m *mongoCommunityStore) GetSymptomReportItems(ctx context.Context, cohort, start, end string) (map[string][]Bucket, *SymptomReportCoverage, error) {
+	reports, err := m.GetSymptomDailyReports(ctx, cohort, start, end)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return SymptomReportItems(reports, start, end)
+}
+
+// SymptomReportItems returns report items of each day from `start` to `end` inclusively from the
+// daily reports, latest first. Days without a report, and symptoms not in the report of a day,
+// are filled with zero values.
+func SymptomReportItems(reports []SymptomDailyReport, start, end string) (map[string][]Bucket, *SymptomReportCoverage, error) {
+	startDate, err := time.Parse(SymptomCheckinDateLayout, start)
+	if err != nil {
+		return nil, nil, err
+	}
+	endDate, err := time.Parse(SymptomCheckinDateLayout, end)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	counts := make(map[string]map[string]int)
+	reported := make(map[string]bool)
+	for _, report := range reports {
+		if report.Date < start || report.Date > end {
+			continue
+		}
+
+		reported[report.Date] = true
+		for _, symptom := range report.Symptoms {
+			if _, ok := counts[symptom.Name]; !ok {
+				counts[symptom.Name] = make(map[string]int)
+			}
+			counts[symptom.Name][report.Date] = symptom.Count
+		}
+	}
+
+	coverage := &SymptomReportCoverage{Start: start, End: end, MissingDates: []string{}}
+	dates := make([]string, 0)
+	for date := endDate; !date.Before(startDate); date = date.AddDate(0, 0, -1) {
+		d := date.Format(SymptomCheckinDateLayout)
+		dates = append(dates, d)
+		if reported[d] {
+			coverage.ReportedDays++
+		} else {
+			coverage.MissingDates = append(coverage.MissingDates, d)
+		}
+	}
+	coverage.Days = len(dates)
+	coverage.Complete = coverage.ReportedDays == coverage.Days
+
+	results := make(map[string][]Bucket)
+	for name, values := range counts {
+		buckets := make([]Bucket, 0, len(dates))
+		for _, d := range dates {
+			buckets = append(buckets, Bucket{Name: d, Value: values[d]})
+		}
+		results[name] = buckets
+	}
+	return results, coverage, nil
+}
+
+// GetSymptomTimeseries returns daily values of each symptom of a cohort from `start` to `end` inclusively, ordered by date
+func (m *mongoCommunityStore) GetSymptomTimeseries(ctx context.Context, cohort, start, end string) (map[string][]Bucket, error) {
+	reports, err := m.GetSymptomDailyReports(ctx, cohort, start, end)
 	if err != nil {
 		return nil, err
 	}
 
+	return SymptomTimeseries(reports), nil
+}
+
+// SymptomTimeseries returns daily values of each symptom from the daily reports, ordered by date.
+// Each symptom has a value for every reported date, which is zero if it is not in the report of the date.
+func SymptomTimeseries(reports []SymptomDailyReport) map[string][]Bucket {
+	sorted := make([]SymptomDailyReport, len(reports))
+	copy(sorted, reports)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Date < sorted[j].Date
+	})
+
+	counts := make(map[string]map[string]int)
+	for _, report := range sorted {
+		for _, symptom := range report.Symptoms {
+			if _, ok := counts[symptom.Name]; !ok {
+				counts[symptom.Name] = make(map[string]int)
+			}
+			counts[symptom.Name][report.Date] = symptom.Count
+		}
+	}
+
 	results := make(map[string][]Bucket)
-	for cursor.Next(ctx) {
-		var aggItem BucketAggregation
-		if err := cursor.Decode(&aggItem); err != nil {
-			return nil, err
+	for name, values := range counts {
+		buckets := make([]Bucket, 0, len(sorted))
+		for i, report := range sorted {
+			if i > 0 && sorted[i-1].Date == report.Date {
+				continue
+			}
+			buckets = append(buckets, Bucket{Name: report.Date, Value: values[report.Date]})
 		}
-		results[aggItem.ID] = aggItem.Buckets
+		results[name] = buckets
 	}
-	return results, nil
+	return results
 }
 
-func (m *mongoCommunityStore) FindLatestDailyReport(ctx context.Context) (*SymptomDailyReport, error) {
+// FindLatestDailyReport returns the latest report of a cohort
+func (m *mongoCommunityStore) FindLatestDailyReport(ctx context.Context, cohort string) (*SymptomDailyReport, error) {
 	var report SymptomDailyReport
 
 	opts := options.FindOne().SetSort(bson.D{{"date", -1}})
-	err := m.Resource("symptom_reports").FindOne(ctx, bson.M{}, opts).Decode(&report)
+	err := m.Resource("symptom_reports").FindOne(ctx, bson.M{"cohort": cohort}, opts).Decode(&report)
 	return &report, err
 }
@@ -0,0 +1,273 @@
+package store
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// SymptomReportBatchPending is the status of a batch whose reports are being applied. A batch
+	// stays pending if it fails to be applied, and its versions are never restored.
+	SymptomReportBatchPending    = "pending"
+	SymptomReportBatchApplied    = "applied"
+	SymptomReportBatchRolledBack = "rolled_back"
+)
+
+func init() {
+	// batches and versions of symptom reports are uploaded by operators and are not linked to any account
+	CommunityResources.Register(Resource{
+		Name:       "symptom_report_batches",
+		Collection: "symptom_report_batches",
+		Indexes: []mongo.IndexModel{
+			{
+				Keys: bson.D{
+					{"created_at", -1},
+				},
+				Options: options.Index().SetName("created_at"),
+			},
+		},
+		Delete: RetainAccountData,
+	})
+
+	CommunityResources.Register(Resource{
+		Name:       "symptom_report_versions",
+		Collection: "symptom_report_versions",
+		Indexes: []mongo.IndexModel{
+			{
+				Keys: bson.D{
+					{"cohort", 1},
+					{"date", 1},
+					{"created_at", -1},
+				},
+				Options: options.Index().SetName("cohort_date_created_at"),
+			},
+		},
+		Delete: RetainAccountData,
+	})
+}
+
+// SymptomReportBatch is an upload of symptom daily reports of a cohort
+type SymptomReportBatch struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Cohort   string             `bson:"cohort" json:"cohort"`
+	Uploader string             `bson:"uploader" json:"uploader"`
+	Format   string             `bson:"format" json:"format"`
+	FileName string             `bson:"file_name,omitempty" json:"file_name,omitempty"`
+	// FileHash is the hex encoded SHA-256 hash of the uploaded file
+	FileHash     string   `bson:"file_hash" json:"file_hash"`
+	Dates        []string `bson:"dates" json:"dates"`
+	Status       string   `bson:"status" json:"status"`
+	CreatedAt    int64    `bson:"created_at" json:"created_at"`
+	RolledBackBy string   `bson:"rolled_back_by,omitempty" json:"rolled_back_by,omitempty"`
+	RolledBackAt int64    `bson:"rolled_back_at,omitempty" json:"rolled_back_at,omitempty"`
+}
+
+// symptomReportVersion is a version of the daily report of a date. Versions of reports
+// which are not uploaded in batches have no batch id.
+type symptomReportVersion struct {
+	SymptomDailyReport `bson:",inline"`
+	BatchID            *primitive.ObjectID `bson:"batch_id"`
+	CreatedAt          int64               `bson:"created_at"`
+}
+
+// AddSymptomReportBatch records a batch and upserts its reports. A version of each report is
+// retained, together with the replaced report if it has not been retained yet. The writes are
+// ordered so that a failed upload can be uploaded again: the batch is pending until all of its
+// reports are replaced, and retaining a replaced report again does not duplicate its version.
+func (m *mongoCommunityStore) AddSymptomReportBatch(ctx context.Context, batch *SymptomReportBatch, reports []SymptomDailyReport) error {
+	batch.Dates = make([]string, 0, len(reports))
+	for i := range reports {
+		reports[i].Cohort = batch.Cohort
+		batch.Dates = append(batch.Dates, reports[i].Date)
+	}
+
+	// the replaced reports are retained as versions older than the ones of the batch
+	retainedAt := nowInMillisecond()
+	if err := m.retainUnversionedReports(ctx, batch.Cohort, batch.Dates, retainedAt); err != nil {
+		return err
+	}
+
+	batch.ID = primitive.NewObjectID()
+	batch.Status = SymptomReportBatchPending
+	batch.CreatedAt = nowInMillisecond()
+	if batch.CreatedAt <= retainedAt {
+		batch.CreatedAt = retainedAt + 1
+	}
+	if _, err := m.Resource("symptom_report_batches").InsertOne(ctx, batch); err != nil {
+		return err
+	}
+
+	versions := make([]interface{}, 0, len(reports))
+	for _, report := range reports {
+		versions = append(versions, symptomReportVersion{
+			SymptomDailyReport: report,
+			BatchID:            &batch.ID,
+			CreatedAt:          batch.CreatedAt,
+		})
+	}
+	if len(versions) > 0 {
+		if _, err := m.Resource("symptom_report_versions").InsertMany(ctx, versions); err != nil {
+			return err
+		}
+	}
+
+	for _, report := range reports {
+		if err := m.replaceSymptomReport(ctx, report, &batch.ID); err != nil {
+			return err
+		}
+	}
+
+	if _, err := m.Resource("symptom_report_batches").UpdateOne(ctx,
+		bson.M{"_id": batch.ID},
+		bson.M{"$set": bson.M{"status": SymptomReportBatchApplied}}); err != nil {
+		return err
+	}
+	batch.Status = SymptomReportBatchApplied
+
+	return nil
+}
+
+// retainUnversionedReports keeps versions of current reports of the dates which are not
+// uploaded in batches, so that they can be restored. A report which is retained already
+// is not duplicated, but its version is moved to the given time.
+func (m *mongoCommunityStore) retainUnversionedReports(ctx context.Context, cohort string, dates []string, retainedAt int64) error {
+	cursor, err := m.Resource("symptom_reports").Find(ctx, bson.M{
+		"cohort":   cohort,
+		"date":     bson.M{"$in": dates},
+		"batch_id": bson.M{"$exists": false},
+	})
+	if err != nil {
+		return err
+	}
+
+	var reports []SymptomDailyReport
+	if err := cursor.All(ctx, &reports); err != nil {
+		return err
+	}
+
+	for _, report := range reports {
+		if _, err := m.Resource("symptom_report_versions").UpdateOne(ctx,
+			bson.M{
+				"cohort":                       report.Cohort,
+				"date":                         report.Date,
+				"symptoms":                     report.Symptoms,
+				"checkins_num_past_three_days": report.CheckinsNumPastThreeDays,
+				"batch_id":                     nil,
+			},
+			bson.M{"$max": bson.M{"created_at": retainedAt}},
+			options.Update().SetUpsert(true)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replaceSymptomReport upserts the current report of a date
+func (m *mongoCommunityStore) replaceSymptomReport(ctx context.Context, report SymptomDailyReport, batchID *primitive.ObjectID) error {
+	update := bson.M{
+		"$set": bson.M{
+			"symptoms":                     report.Symptoms,
+			"checkins_num_past_three_days": report.CheckinsNumPastThreeDays,
+		},
+	}
+	if batchID != nil {
+		update["$set"].(bson.M)["batch_id"] = *batchID
+	} else {
+		update["$unset"] = bson.M{"batch_id": ""}
+	}
+
+	_, err := m.Resource("symptom_reports").UpdateOne(ctx, bson.M{"cohort": report.Cohort, "date": report.Date}, update, options.Update().SetUpsert(true))
+	return err
+}
+
+// ListSymptomReportBatches returns batches of a cohort, or of all cohorts if it is empty, latest first
+func (m *mongoCommunityStore) ListSymptomReportBatches(ctx context.Context, cohort string, limit int64) ([]SymptomReportBatch, error) {
+	filter := bson.M{}
+	if cohort != "" {
+		filter["cohort"] = cohort
+	}
+
+	cursor, err := m.Resource("symptom_report_batches").Find(ctx, filter,
+		options.Find().SetSort(bson.D{{"created_at", -1}, {"_id", -1}}).SetLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+
+	batches := []SymptomReportBatch{}
+	if err := cursor.All(ctx, &batches); err != nil {
+		return nil, err
+	}
+	return batches, nil
+}
+
+// RollbackSymptomReportBatch marks a batch as rolled back, and restores the latest version of
+// each report which is currently from the batch, among the versions not uploaded in batches and
+// the ones of applied batches. A report without any remaining
+// version is removed. Reports replaced by later batches are kept. Rolling back a batch again
+// repeats the restoration, so an interrupted rollback can be completed. It returns
+// mongo.ErrNoDocuments if the batch is not found.
+func (m *mongoCommunityStore) RollbackSymptomReportBatch(ctx context.Context, batchID, rolledBackBy string) (*SymptomReportBatch, error) {
+	id, err := primitive.ObjectIDFromHex(batchID)
+	if err != nil {
+		return nil, mongo.ErrNoDocuments
+	}
+
+	var batch SymptomReportBatch
+	if err := m.Resource("symptom_report_batches").FindOneAndUpdate(ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{
+			"status":         SymptomReportBatchRolledBack,
+			"rolled_back_by": rolledBackBy,
+			"rolled_back_at": nowInMillisecond(),
+		}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&batch); err != nil {
+		return nil, err
+	}
+
+	applied, err := m.Resource("symptom_report_batches").Distinct(ctx, "_id", bson.M{"status": SymptomReportBatchApplied})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, date := range batch.Dates {
+		var current struct {
+			BatchID *primitive.ObjectID `bson:"batch_id"`
+		}
+		err := m.Resource("symptom_reports").FindOne(ctx, bson.M{"cohort": batch.Cohort, "date": date}).Decode(&current)
+		if err == mongo.ErrNoDocuments {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if current.BatchID == nil || *current.BatchID != id {
+			continue
+		}
+
+		var version symptomReportVersion
+		err = m.Resource("symptom_report_versions").FindOne(ctx,
+			bson.M{"cohort": batch.Cohort, "date": date, "$or": bson.A{
+				bson.M{"batch_id": nil},
+				bson.M{"batch_id": bson.M{"$in": applied}},
+			}},
+			options.FindOne().SetSort(bson.D{{"created_at", -1}, {"_id", -1}})).Decode(&version)
+		switch err {
+		case nil:
+			if err := m.replaceSymptomReport(ctx, version.SymptomDailyReport, version.BatchID); err != nil {
+				return nil, err
+			}
+		case mongo.ErrNoDocuments:
+			if _, err := m.Resource("symptom_reports").DeleteOne(ctx, bson.M{"cohort": batch.Cohort, "date": date}); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, err
+		}
+	}
+
+	return &batch, nil
+}
@@ -0,0 +1,194 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SymptomReportBatchTestSuite struct {
+	suite.Suite
+	connURI     string
+	mongoClient *mongo.Client
+}
+
+func NewSymptomReportBatchTestSuite(connURI string) *SymptomReportBatchTestSuite {
+	return &SymptomReportBatchTestSuite{
+		connURI: connURI,
+	}
+}
+
+func (s *SymptomReportBatchTestSuite) SetupSuite() {
+	if s.connURI == "" {
+		s.T().Fatal("invalid test suite configuration")
+	}
+
+	opts := options.Client().ApplyURI(s.connURI)
+	mongoClient, err := mongo.NewClient(opts)
+	if nil != err {
+		s.T().Fatalf("create mongo client with error: %s", err)
+	}
+
+	ctx := context.Background()
+	if err = mongoClient.Connect(ctx); nil != err {
+		s.T().Fatalf("connect mongo database with error: %s", err.Error())
+	}
+
+	s.mongoClient = mongoClient
+
+	dbNames, err := mongoClient.ListDatabaseNames(ctx, bson.M{"name": primitive.Regex{Pattern: "^testcase_"}})
+	if err != nil {
+		s.T().Fatalf("list all databases with error: %s", err.Error())
+	}
+
+	for _, name := range dbNames {
+		if err := mongoClient.Database(name).Drop(ctx); err != nil {
+			s.T().Fatalf("drop database with error: %s", err.Error())
+		}
+	}
+
+	if err := newTestDataPool(s.mongoClient).InitCommunityStore(); err != nil {
+		s.T().Fatalf("init community store with error: %s", err.Error())
+	}
+}
+
+func (s *SymptomReportBatchTestSuite) findReport(ctx context.Context, date string) (*SymptomDailyReport, error) {
+	var report SymptomDailyReport
+	err := newTestDataPool(s.mongoClient).Community().(*mongoCommunityStore).
+		Resource("symptom_reports").FindOne(ctx, bson.M{"cohort": testDefaultCohort, "date": date}).Decode(&report)
+	return &report, err
+}
+
+func (s *SymptomReportBatchTestSuite) TestRollbackSymptomReportBatch() {
+	ctx := context.Background()
+	store := newTestDataPool(s.mongoClient).Community()
+
+	// a report uploaded before batches are recorded
+	s.NoError(store.AddSymptomDailyReports(ctx, []SymptomDailyReport{
+		{Cohort: testDefaultCohort, Date: "2020-08-01", Symptoms: []SymptomStats{{Name: "cough", Count: 1}}, CheckinsNumPastThreeDays: 10},
+	}))
+
+	first := SymptomReportBatch{Cohort: testDefaultCohort, Uploader: "admin", Format: "wide_csv", FileHash: "h1"}
+	s.NoError(store.AddSymptomReportBatch(ctx, &first, []SymptomDailyReport{
+		{Date: "2020-08-01", Symptoms: []SymptomStats{{Name: "cough", Count: 2}}, CheckinsNumPastThreeDays: 20},
+		{Date: "2020-08-02", Symptoms: []SymptomStats{{Name: "cough", Count: 3}}, CheckinsNumPastThreeDays: 30},
+	}))
+	s.Equal([]string{"2020-08-01", "2020-08-02"}, first.Dates)
+	s.Equal(SymptomReportBatchApplied, first.Status)
+
+	second := SymptomReportBatch{Cohort: testDefaultCohort, Uploader: "admin", Format: "json", FileHash: "h2"}
+	s.NoError(store.AddSymptomReportBatch(ctx, &second, []SymptomDailyReport{
+		{Date: "2020-08-02", Symptoms: []SymptomStats{{Name: "cough", Count: 4}}, CheckinsNumPastThreeDays: 40},
+		{Date: "2020-08-03", Symptoms: []SymptomStats{{Name: "fever", Count: 5}}, CheckinsNumPastThreeDays: 50},
+	}))
+
+	batches, err := store.ListSymptomReportBatches(ctx, testDefaultCohort, 10)
+	s.NoError(err)
+	s.Len(batches, 2)
+	s.Equal(second.ID, batches[0].ID)
+
+	// the second batch is rolled back to the first batch, or removed
+	batch, err := store.RollbackSymptomReportBatch(ctx, second.ID.Hex(), "operator")
+	s.NoError(err)
+	s.Equal(SymptomReportBatchRolledBack, batch.Status)
+	s.Equal("operator", batch.RolledBackBy)
+
+	report, err := s.findReport(ctx, "2020-08-02")
+	s.NoError(err)
+	s.Equal(30, report.CheckinsNumPastThreeDays)
+	_, err = s.findReport(ctx, "2020-08-03")
+	s.Equal(mongo.ErrNoDocuments, err)
+
+	// the first batch is rolled back to the report uploaded before it
+	_, err = store.RollbackSymptomReportBatch(ctx, first.ID.Hex(), "operator")
+	s.NoError(err)
+
+	report, err = s.findReport(ctx, "2020-08-01")
+	s.NoError(err)
+	s.Equal(10, report.CheckinsNumPastThreeDays)
+	_, err = s.findReport(ctx, "2020-08-02")
+	s.Equal(mongo.ErrNoDocuments, err)
+
+	_, err = store.RollbackSymptomReportBatch(ctx, primitive.NewObjectID().Hex(), "operator")
+	s.Equal(mongo.ErrNoDocuments, err)
+	_, err = store.RollbackSymptomReportBatch(ctx, "invalid", "operator")
+	s.Equal(mongo.ErrNoDocuments, err)
+}
+
+func (s *SymptomReportBatchTestSuite) TestAddSymptomDailyReportsKeepsBatches() {
+	ctx := context.Background()
+	store := newTestDataPool(s.mongoClient).Community()
+
+	batch := SymptomReportBatch{Cohort: testDefaultCohort, Uploader: "admin", Format: "json", FileHash: "h3"}
+	s.NoError(store.AddSymptomReportBatch(ctx, &batch, []SymptomDailyReport{
+		{Date: "2020-09-01", Symptoms: []SymptomStats{{Name: "cough", Count: 7}}, CheckinsNumPastThreeDays: 70},
+	}))
+
+	// aggregated reports do not replace the uploaded one
+	s.NoError(store.AddSymptomDailyReports(ctx, []SymptomDailyReport{
+		{Cohort: testDefaultCohort, Date: "2020-09-01", Symptoms: []SymptomStats{{Name: "cough", Count: 1}}, CheckinsNumPastThreeDays: 3},
+		{Cohort: testDefaultCohort, Date: "2020-09-02", Symptoms: []SymptomStats{{Name: "cough", Count: 2}}, CheckinsNumPastThreeDays: 4},
+	}))
+
+	report, err := s.findReport(ctx, "2020-09-01")
+	s.NoError(err)
+	s.Equal(70, report.CheckinsNumPastThreeDays)
+	report, err = s.findReport(ctx, "2020-09-02")
+	s.NoError(err)
+	s.Equal(4, report.CheckinsNumPastThreeDays)
+}
+
+func (s *SymptomReportBatchTestSuite) TestRollbackToReportWithoutBatch() {
+	ctx := context.Background()
+	store := newTestDataPool(s.mongoClient).Community()
+
+	first := SymptomReportBatch{Cohort: testDefaultCohort, Uploader: "admin", Format: "json", FileHash: "h4"}
+	s.NoError(store.AddSymptomReportBatch(ctx, &first, []SymptomDailyReport{
+		{Date: "2020-10-01", Symptoms: []SymptomStats{{Name: "cough", Count: 1}}, CheckinsNumPastThreeDays: 10},
+	}))
+
+	// the report of the first batch is replaced without a batch, as check-in aggregations did
+	s.NoError(store.(*mongoCommunityStore).replaceSymptomReport(ctx, SymptomDailyReport{
+		Cohort: testDefaultCohort, Date: "2020-10-01", Symptoms: []SymptomStats{{Name: "cough", Count: 2}}, CheckinsNumPastThreeDays: 20,
+	}, nil))
+
+	second := SymptomReportBatch{Cohort: testDefaultCohort, Uploader: "admin", Format: "json", FileHash: "h5"}
+	s.NoError(store.AddSymptomReportBatch(ctx, &second, []SymptomDailyReport{
+		{Date: "2020-10-01", Symptoms: []SymptomStats{{Name: "cough", Count: 3}}, CheckinsNumPastThreeDays: 30},
+	}))
+	s.Equal(SymptomReportBatchApplied, second.Status)
+
+	third := SymptomReportBatch{Cohort: testDefaultCohort, Uploader: "admin", Format: "json", FileHash: "h6"}
+	s.NoError(store.AddSymptomReportBatch(ctx, &third, []SymptomDailyReport{
+		{Date: "2020-10-02", Symptoms: []SymptomStats{{Name: "cough", Count: 4}}, CheckinsNumPastThreeDays: 40},
+	}))
+	s.NoError(store.AddSymptomDailyReports(ctx, []SymptomDailyReport{
+		{Cohort: testDefaultCohort, Date: "2020-10-03", Symptoms: []SymptomStats{{Name: "cough", Count: 5}}, CheckinsNumPastThreeDays: 50},
+	}))
+	fourth := SymptomReportBatch{Cohort: testDefaultCohort, Uploader: "admin", Format: "json", FileHash: "h7"}
+	s.NoError(store.AddSymptomReportBatch(ctx, &fourth, []SymptomDailyReport{
+		{Date: "2020-10-03", Symptoms: []SymptomStats{{Name: "cough", Count: 6}}, CheckinsNumPastThreeDays: 60},
+	}))
+
+	// the report replaced by the second batch is restored instead of the one of an earlier batch
+	_, err := store.RollbackSymptomReportBatch(ctx, second.ID.Hex(), "operator")
+	s.NoError(err)
+	report, err := s.findReport(ctx, "2020-10-01")
+	s.NoError(err)
+	s.Equal(20, report.CheckinsNumPastThreeDays)
+
+	_, err = store.RollbackSymptomReportBatch(ctx, fourth.ID.Hex(), "operator")
+	s.NoError(err)
+	report, err = s.findReport(ctx, "2020-10-03")
+	s.NoError(err)
+	s.Equal(50, report.CheckinsNumPastThreeDays)
+}
+
+func TestSymptomReportBatch(t *testing.T) {
+	suite.Run(t, NewSymptomReportBatchTestSuite("mongodb://127.0.0.1:27017/?compressors=disabled"))
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -14,7 +15,8 @@ import (
 var (
 	reports = []interface{}{
 		SymptomDailyReport{
-			Date: "2020-07-19",
+			Cohort: testDefaultCohort,
+			Date:   "2020-07-19",
 			Symptoms: []SymptomStats{
 				{Name: "Cough", Count: 10},
 				{Name: "Fatigue", Count: 18},
@@ -22,7 +24,8 @@ var (
 			CheckinsNumPastThreeDays: 0,
 		},
 		SymptomDailyReport{
-			Date: "2020-07-20",
+			Cohort: testDefaultCohort,
+			Date:   "2020-07-20",
 			Symptoms: []SymptomStats{
 				{Name: "Cough", Count: 7},
 				{Name: "Fatigue", Count: 28},
@@ -30,12 +33,21 @@ var (
 			CheckinsNumPastThreeDays: 0,
 		},
 		SymptomDailyReport{
-			Date: "2020-07-21",
+			Cohort: testDefaultCohort,
+			Date:   "2020-07-21",
 			Symptoms: []SymptomStats{
 				{Name: "Cough", Count: 8},
 				{Name: "Fatigue", Count: 29},
 			},
 			CheckinsNumPastThreeDays: 1003,
+		},
+		SymptomDailyReport{
+			Cohort: "testcase_other_cohort",
+			Date:   "2020-07-22",
+			Symptoms: []SymptomStats{
+				{Name: "Cough", Count: 1},
+			},
+			CheckinsNumPastThreeDays: 20,
 		}}
 )
 
@@ -96,35 +108,69 @@ func (s *SymptomReportTestSuite) LoadFixtures() error {
 }
 
 func (s *SymptomReportTestSuite) TestCommunityGetSymptomReportItems() {
-	dataPool := NewMongodbDataPool(s.mongoClient, TestDBPrefix)
+	dataPool := newTestDataPool(s.mongoClient)
 
 	ctx := context.Background()
-	items, err := dataPool.Community().GetSymptomReportItems(ctx, "2020-07-21", 7)
+	items, coverage, err := dataPool.Community().GetSymptomReportItems(ctx, testDefaultCohort, "2020-07-18", "2020-07-21")
 	s.NoError(err)
 	s.Equal(map[string][]Bucket{
 		"Cough": {
 			{"2020-07-21", 8},
 			{"2020-07-20", 7},
 			{"2020-07-19", 10},
+			{"2020-07-18", 0},
 		},
 		"Fatigue": {
 			{"2020-07-21", 29},
 			{"2020-07-20", 28},
 			{"2020-07-19", 18},
+			{"2020-07-18", 0},
 		},
 	}, items)
+	s.Equal(&SymptomReportCoverage{
+		Start:        "2020-07-18",
+		End:          "2020-07-21",
+		Days:         4,
+		ReportedDays: 3,
+		MissingDates: []string{"2020-07-18"},
+		Complete:     false,
+	}, coverage)
+
+	items, coverage, err = dataPool.Community().GetSymptomReportItems(ctx, testDefaultCohort, "2020-07-20", "2020-07-21")
+	s.NoError(err)
+	s.Len(items["Cough"], 2)
+	s.True(coverage.Complete)
 
-	items, err = dataPool.Community().GetSymptomReportItems(ctx, "2020-07-18", 7)
+	items, coverage, err = dataPool.Community().GetSymptomReportItems(ctx, testDefaultCohort, "2020-07-12", "2020-07-18")
 	s.NoError(err)
 	s.Equal(0, len(items))
+	s.Equal(0, coverage.ReportedDays)
+	s.Len(coverage.MissingDates, 7)
+}
+
+func (s *SymptomReportTestSuite) TestCommunityGetSymptomTimeseries() {
+	ctx := context.Background()
+	series, err := newTestDataPool(s.mongoClient).Community().GetSymptomTimeseries(ctx, testDefaultCohort, "2020-07-20", "2020-07-25")
+	s.NoError(err)
+	s.Equal(map[string][]Bucket{
+		"Cough": {
+			{"2020-07-20", 7},
+			{"2020-07-21", 8},
+		},
+		"Fatigue": {
+			{"2020-07-20", 28},
+			{"2020-07-21", 29},
+		},
+	}, series)
 }
 
 func (s *SymptomReportTestSuite) TestCommunityFindLatestDailyReport() {
 	ctx := context.Background()
-	report, err := NewMongodbDataPool(s.mongoClient, TestDBPrefix).Community().FindLatestDailyReport(ctx)
+	report, err := newTestDataPool(s.mongoClient).Community().FindLatestDailyReport(ctx, testDefaultCohort)
 	s.NoError(err)
 	s.Equal(&SymptomDailyReport{
-		Date: "2020-07-21",
+		Cohort: testDefaultCohort,
+		Date:   "2020-07-21",
 		Symptoms: []SymptomStats{
 			{Name: "Cough", Count: 8},
 			{Name: "Fatigue", Count: 29},
@@ -136,3 +182,16 @@ func (s *SymptomReportTestSuite) TestCommunityFindLatestDailyReport() {
 func TestSymptomReport(t *testing.T) {
 	suite.Run(t, NewSymptomReportTestSuite("mongodb://127.0.0.1:27017/?compressors=disabled"))
 }
+
+func TestSymptomTimeseries(t *testing.T) {
+	series := SymptomTimeseries([]SymptomDailyReport{
+		{Date: "2020-07-21", Symptoms: []SymptomStats{{Name: "cough", Count: 8}}},
+		{Date: "2020-07-20", Symptoms: []SymptomStats{{Name: "cough", Count: 7}, {Name: "fever", Count: 2}}},
+		{Date: "2020-07-23", Symptoms: []SymptomStats{{Name: "fever", Count: 3}}},
+	})
+
+	assert.Equal(t, map[string][]Bucket{
+		"cough": {{Name: "2020-07-20", Value: 7}, {Name: "2020-07-21", Value: 8}, {Name: "2020-07-23", Value: 0}},
+		"fever": {{Name: "2020-07-20", Value: 2}, {Name: "2020-07-21", Value: 0}, {Name: "2020-07-23", Value: 3}},
+	}, series)
+}
@@ -1,6 +1,7 @@
 package web
 
 import (
+	"crypto/subtle"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -78,13 +79,7 @@ func (s *Server) CheckMacaroon() gin.HandlerFunc {
 					}
 				}
 			case "resources":
-				path := c.Request.URL.Path
-				for _, param := range c.Params {
-					path = strings.Replace(path, param.Value, "", -1)
-				}
-				path = strings.TrimRight(path, "/")
-				parts := strings.Split(path, "/")
-				targetResource := parts[len(parts)-1]
+				targetResource := targetResource(c)
 				allowedResources := strings.Split(arg, ",")
 				valid := false
 				for _, r := range allowedResources {
@@ -115,6 +110,62 @@ func (s *Server) CheckMacaroon() gin.HandlerFunc {
 	}
 }
 
+// targetResource returns the name of the resource a request is going to access.
+// Documents under `/resources/:name` are governed by the name of their collection.
+func targetResource(c *gin.Context) string {
+	if strings.HasPrefix(c.FullPath(), "/resources/:name") {
+		return c.Param("name")
+	}
+
+	path := c.Request.URL.Path
+	for _, param := range c.Params {
+		path = strings.Replace(path, param.Value, "", -1)
+	}
+	path = strings.TrimRight(path, "/")
+	parts := strings.Split(path, "/")
+	return parts[len(parts)-1]
+}
+
+// CheckServiceToken authorizes requests from other services which act on behalf of
+// an account. The account is given by the X-ACCOUNT-NUMBER header.
+func (s *Server) CheckServiceToken(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		auth := c.GetHeader("Authorization")
+		bearerTexts := strings.Split(auth, "Bearer ")
+		if token == "" || len(bearerTexts) != 2 || subtle.ConstantTimeCompare([]byte(bearerTexts[1]), []byte(token)) != 1 {
+			abortWithErrorMessage(c, http.StatusForbidden, errorResponse{Message: "invalid service token"})
+			return
+		}
+
+		accountNumber := c.GetHeader("X-ACCOUNT-NUMBER")
+		if accountNumber == "" {
+			abortWithErrorMessage(c, http.StatusBadRequest, errorResponse{Message: "no account number"})
+			return
+		}
+		c.Set("account_number", accountNumber)
+
+		c.Next()
+	}
+}
+
+// CheckAdmin allows only the given accounts to proceed. It is used after CheckMacaroon,
+// which identifies the account of a request.
+func (s *Server) CheckAdmin(accounts []string) gin.HandlerFunc {
+	admins := make(map[string]struct{}, len(accounts))
+	for _, a := range accounts {
+		admins[a] = struct{}{}
+	}
+
+	return func(c *gin.Context) {
+		if _, ok := admins[c.GetString("account_number")]; !ok {
+			abortWithErrorMessage(c, http.StatusForbidden, errorResponse{Message: "admin access required"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
 func parseCaveat(cav string) (string, string, string, error) {
 	if cav == "" {
 		return "", "", "", fmt.Errorf("empty caveat")
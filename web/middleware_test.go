@@ -0,0 +1,102 @@
+package web
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/macaroon.v2"
+)
+
+func TestCheckMacaroonResources(t *testing.T) {
+	rootKey := []byte("ROOT KEY")
+	s := NewServer(false, nil, "localhost", rootKey)
+
+	m, err := macaroon.New(rootKey, []byte("account"), "localhost", macaroon.V1)
+	assert.NoError(t, err)
+	assert.NoError(t, m.AddFirstPartyCaveat([]byte("entity = account")))
+	assert.NoError(t, m.AddFirstPartyCaveat([]byte("action = read")))
+	assert.NoError(t, m.AddFirstPartyCaveat([]byte("resources in poi_rating,notes")))
+	data, err := m.MarshalBinary()
+	assert.NoError(t, err)
+	token := base64.RawURLEncoding.EncodeToString(data)
+
+	r := gin.New()
+	ok := func(c *gin.Context) { c.String(http.StatusOK, c.GetString("account_number")) }
+	r.GET("/poi_rating/:poi_id", s.CheckMacaroon(), ok)
+	r.GET("/resources/:name", s.CheckMacaroon(), ok)
+	r.GET("/resources/:name/:id", s.CheckMacaroon(), ok)
+
+	for path, code := range map[string]int{
+		"/poi_rating/abc":       http.StatusOK,
+		"/resources/notes":      http.StatusOK,
+		"/resources/notes/n1":   http.StatusOK,
+		"/resources/secrets/n1": http.StatusForbidden,
+		"/resources/secrets":    http.StatusForbidden,
+	} {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, code, w.Code, path)
+		if code == http.StatusOK {
+			assert.Equal(t, "account", w.Body.String())
+		}
+	}
+}
+
+func TestCheckServiceToken(t *testing.T) {
+	s := NewServer(false, nil, "localhost", []byte("ROOT KEY"))
+
+	r := gin.New()
+	r.PUT("/service", s.CheckServiceToken("secret"), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("account_number"))
+	})
+
+	for _, tc := range []struct {
+		auth    string
+		account string
+		code    int
+	}{
+		{"Bearer secret", "account", http.StatusOK},
+		{"Bearer secret", "", http.StatusBadRequest},
+		{"Bearer wrong", "account", http.StatusForbidden},
+		{"", "account", http.StatusForbidden},
+	} {
+		req := httptest.NewRequest("PUT", "/service", nil)
+		req.Header.Set("Authorization", tc.auth)
+		req.Header.Set("X-ACCOUNT-NUMBER", tc.account)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, tc.code, w.Code, tc.auth)
+		if tc.code == http.StatusOK {
+			assert.Equal(t, "account", w.Body.String())
+		}
+	}
+}
+
+func TestCheckAdmin(t *testing.T) {
+	s := NewServer(false, nil, "localhost", []byte("ROOT KEY"))
+
+	r := gin.New()
+	r.GET("/admin", func(c *gin.Context) {
+		c.Set("account_number", c.GetHeader("X-ACCOUNT-NUMBER"))
+	}, s.CheckAdmin([]string{"admin"}), func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	for account, code := range map[string]int{
+		"admin":   http.StatusOK,
+		"account": http.StatusForbidden,
+		"":        http.StatusForbidden,
+	} {
+		req := httptest.NewRequest("GET", "/admin", nil)
+		req.Header.Set("X-ACCOUNT-NUMBER", account)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, code, w.Code, account)
+	}
+}